@@ -4,6 +4,7 @@ import (
 	"fmt"
 	os2 "os"
 	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/cometbft/cometbft/libs/os"
@@ -60,6 +61,59 @@ func SafeWrite(file string, contents []byte, logger *log.Logger) error {
 	return nil
 }
 
+// AtomicWrite writes contents to a file such that a crash never leaves a partially written file. Contents are written to a temporary file in
+// the same directory, synced, and then renamed over the target.
+func AtomicWrite(file string, contents []byte, perm os2.FileMode) error {
+	expanded := ExpandHomeDir(file)
+	directory := filepath.Dir(expanded)
+
+	tempFile, err := os2.CreateTemp(directory, filepath.Base(expanded)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempFileName := tempFile.Name()
+
+	// Clean up the temporary file if anything goes wrong. After a successful rename this is a no-op.
+	defer os2.Remove(tempFileName)
+
+	_, err = tempFile.Write(contents)
+	if err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	err = tempFile.Chmod(perm)
+	if err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	err = tempFile.Sync()
+	if err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	err = tempFile.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os2.Rename(tempFileName, expanded)
+	if err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename is durable. Not all platforms support this, so failures are ignored.
+	dir, err := os2.Open(directory)
+	if err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
+
 func ExpandHomeDir(path string) string {
 	if !strings.HasPrefix(path, "~") {
 		return path
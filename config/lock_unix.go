@@ -0,0 +1,35 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockFile takes an exclusive advisory lock on a file, creating it if needed, and blocks until the lock is acquired. The returned function
+// releases the lock.
+func LockFile(file string) (func() error, error) {
+	expanded := ExpandHomeDir(file)
+
+	lockFile, err := os.OpenFile(expanded, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		lockFile.Close()
+		return nil, err
+	}
+
+	unlock := func() error {
+		err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+		if err != nil {
+			lockFile.Close()
+			return err
+		}
+		return lockFile.Close()
+	}
+	return unlock, nil
+}
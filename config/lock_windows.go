@@ -0,0 +1,19 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+)
+
+// LockFile creates the lock file, but does not take a lock. Advisory locks are not supported on Windows.
+func LockFile(file string) (func() error, error) {
+	expanded := ExpandHomeDir(file)
+
+	lockFile, err := os.OpenFile(expanded, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockFile.Close, nil
+}
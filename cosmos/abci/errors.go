@@ -0,0 +1,43 @@
+package abci
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of ABCI errors. Errors from tx results wrap one of these when their codespace and code are recognized, so callers can use errors.Is.
+var (
+	ErrInsufficientFee    = errors.New("insufficient fee")
+	ErrOutOfGas           = errors.New("out of gas")
+	ErrSequenceMismatch   = errors.New("account sequence mismatch")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrTxInMempoolCache   = errors.New("tx already in mempool cache")
+	ErrMempoolFull        = errors.New("mempool is full")
+	ErrAuthzGrantNotFound = errors.New("authz grant not found")
+
+	// The fee was not acceptable regardless of its amount, for instance because of its denom. Raising the gas price does not help.
+	ErrInvalidFee = errors.New("invalid fee")
+)
+
+// Error is a failing ABCI result from a tx.
+type Error struct {
+	Codespace string
+	Code      uint32
+	RawLog    string
+
+	// The kind of error, or nil if the codespace and code were not recognized
+	Kind error
+}
+
+// The raw log is what the chain reported, so it is the most useful message.
+func (e *Error) Error() string {
+	if e.RawLog != "" {
+		return e.RawLog
+	}
+	return fmt.Sprintf("code %d in codespace %s", e.Code, e.Codespace)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
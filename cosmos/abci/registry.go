@@ -0,0 +1,159 @@
+package abci
+
+import (
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Codespaces used by the Cosmos SDK
+const (
+	sdkCodespace   = "sdk"
+	authzCodespace = "authz"
+)
+
+// Codespace used by Gaia's global fee module
+const gaiaCodespace = "gaia"
+
+// Codespaces used by chain specific modules
+const (
+	evmCodespace    = "evm"
+	txfeesCodespace = "txfees"
+)
+
+// A codespace and code
+type errorCode struct {
+	codespace string
+	code      uint32
+}
+
+// Registry maps codespaces and codes to kinds of errors.
+//
+// Registries may have a parent, which is consulted for codes the registry does not know. Chains with their own modules register their
+// codes in a registry of their own, whose parent is Default, so codes from one chain's modules do not affect other chains.
+type Registry struct {
+	parent *Registry
+
+	kinds map[errorCode]error
+	lock  *sync.RWMutex
+}
+
+// NewRegistry creates an empty registry. parent may be nil.
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{
+		parent: parent,
+
+		kinds: make(map[errorCode]error),
+		lock:  &sync.RWMutex{},
+	}
+}
+
+// Register maps a codespace and code to a kind of error, replacing any existing mapping in this registry.
+func (r *Registry) Register(codespace string, code uint32, kind error) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.kinds[errorCode{codespace: codespace, code: code}] = kind
+	return r
+}
+
+// Kind returns the kind of error for a codespace and code, or nil if it is not recognized.
+func (r *Registry) Kind(codespace string, code uint32) error {
+	r.lock.RLock()
+	kind, found := r.kinds[errorCode{codespace: codespace, code: code}]
+	r.lock.RUnlock()
+
+	if !found && r.parent != nil {
+		return r.parent.Kind(codespace, code)
+	}
+	return kind
+}
+
+// FromCode returns an *Error for a failing code, or nil if the code is zero.
+func (r *Registry) FromCode(codespace string, code uint32, rawLog string) error {
+	// Note: Zero codes do not have a codespace on them
+	if code == 0 {
+		return nil
+	}
+
+	return &Error{
+		Codespace: codespace,
+		Code:      code,
+		RawLog:    rawLog,
+		Kind:      r.Kind(codespace, code),
+	}
+}
+
+// FromTxResponse returns an *Error for a failing tx response, or nil if the tx succeeded.
+func (r *Registry) FromTxResponse(txResponse *sdk.TxResponse) error {
+	if txResponse == nil {
+		return nil
+	}
+	return r.FromCode(txResponse.Codespace, txResponse.Code, txResponse.RawLog)
+}
+
+// Default recognizes the Cosmos SDK's codes, and codes from widely used modules whose codespaces are unambiguous.
+var Default = NewRegistry(nil).
+	Register(sdkCodespace, 4, ErrUnauthorized).
+	Register(sdkCodespace, 5, ErrInsufficientFunds).
+	Register(sdkCodespace, 11, ErrOutOfGas).
+	Register(sdkCodespace, 13, ErrInsufficientFee).
+	Register(sdkCodespace, 19, ErrTxInMempoolCache).
+	Register(sdkCodespace, 20, ErrMempoolFull).
+	Register(sdkCodespace, 32, ErrSequenceMismatch).
+	Register(authzCodespace, 2, ErrAuthzGrantNotFound).
+	Register(gaiaCodespace, 4, ErrInsufficientFee)
+
+// Evmos recognizes the EVM module's codes for gas prices and fees which are malformed or out of bounds. Fees below the base fee or minimum gas
+// price are reported with the Cosmos SDK's codes.
+var Evmos = NewRegistry(Default).
+	Register(evmCodespace, 8, ErrInvalidFee).
+	Register(evmCodespace, 9, ErrInvalidFee)
+
+// Osmosis recognizes the txfees module's codes for fees paid in more than one denom, or in a denom which is not a fee token. Fees below the
+// minimum are reported with the Cosmos SDK's codes.
+var Osmosis = NewRegistry(Default).
+	Register(txfeesCodespace, 2, ErrInvalidFee).
+	Register(txfeesCodespace, 3, ErrInvalidFee)
+
+// Registries for individual chains, keyed by chain name. Chains with presets start with them.
+var (
+	chainRegistries = map[string]*Registry{
+		"evmos":   Evmos,
+		"osmosis": Osmosis,
+	}
+	chainRegistriesLock = &sync.Mutex{}
+)
+
+// ForChain returns the registry for a chain, or Default if the chain has no preset and has not registered any codes.
+func ForChain(chainName string) *Registry {
+	chainRegistriesLock.Lock()
+	defer chainRegistriesLock.Unlock()
+
+	registry, found := chainRegistries[chainName]
+	if !found {
+		return Default
+	}
+	return registry
+}
+
+// RegisterChain returns the registry for a chain, creating it if needed, so that codes from the chain's modules can be registered. For
+// instance:
+//
+//	abci.RegisterChain("mychain").Register("mymodule", 7, abci.ErrInsufficientFee)
+func RegisterChain(chainName string) *Registry {
+	chainRegistriesLock.Lock()
+	defer chainRegistriesLock.Unlock()
+
+	registry, found := chainRegistries[chainName]
+	if !found {
+		registry = NewRegistry(Default)
+		chainRegistries[chainName] = registry
+	}
+	return registry
+}
+
+// FromTxResponse returns an *Error for a failing tx response using Default, or nil if the tx succeeded.
+func FromTxResponse(txResponse *sdk.TxResponse) error {
+	return Default.FromTxResponse(txResponse)
+}
@@ -0,0 +1,49 @@
+package abci_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestRegistry_ClassifiesSDKCodes(t *testing.T) {
+	err := abci.FromTxResponse(&sdk.TxResponse{Codespace: "sdk", Code: 13, RawLog: "insufficient fees; got: 1uatom required: 2uatom: insufficient fee"})
+	require.ErrorIs(t, err, abci.ErrInsufficientFee)
+	require.Equal(t, "insufficient fees; got: 1uatom required: 2uatom: insufficient fee", err.Error())
+
+	abciErr := &abci.Error{}
+	require.True(t, errors.As(err, &abciErr))
+	require.Equal(t, uint32(13), abciErr.Code)
+
+	require.ErrorIs(t, abci.FromTxResponse(&sdk.TxResponse{Codespace: "authz", Code: 2}), abci.ErrAuthzGrantNotFound)
+	require.NoError(t, abci.FromTxResponse(&sdk.TxResponse{Code: 0}))
+
+	// Unrecognized codes are still errors, but of no known kind
+	err = abci.FromTxResponse(&sdk.TxResponse{Codespace: "wasm", Code: 5, RawLog: "execute wasm contract failed"})
+	require.Error(t, err)
+	require.Nil(t, errors.Unwrap(err))
+}
+
+func TestRegistry_ChainsExtendTheDefault(t *testing.T) {
+	abci.RegisterChain("testchain").Register("feemodule", 7, abci.ErrInsufficientFee)
+
+	require.ErrorIs(t, abci.ForChain("testchain").FromCode("feemodule", 7, ""), abci.ErrInsufficientFee)
+	require.ErrorIs(t, abci.ForChain("testchain").FromCode("sdk", 11, ""), abci.ErrOutOfGas)
+
+	// Other chains are unaffected
+	require.Nil(t, abci.ForChain("otherchain").Kind("feemodule", 7))
+}
+
+func TestRegistry_ChainPresets(t *testing.T) {
+	require.ErrorIs(t, abci.ForChain("evmos").FromCode("evm", 9, ""), abci.ErrInvalidFee)
+	require.ErrorIs(t, abci.ForChain("evmos").FromCode("sdk", 13, ""), abci.ErrInsufficientFee)
+	require.ErrorIs(t, abci.ForChain("osmosis").FromCode("txfees", 3, ""), abci.ErrInvalidFee)
+	require.ErrorIs(t, abci.ForChain("osmosis").FromCode("sdk", 11, ""), abci.ErrOutOfGas)
+
+	// Presets are not shared with other chains
+	require.Nil(t, abci.ForChain("cosmoshub").Kind("txfees", 3))
+}
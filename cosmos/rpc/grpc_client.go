@@ -9,7 +9,11 @@ import (
 	"github.com/tessellated-io/pickaxe/cosmos/util"
 	"github.com/tessellated-io/pickaxe/grpc"
 	"github.com/tessellated-io/pickaxe/log"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/query"
@@ -24,15 +28,22 @@ import (
 // Page size to use
 const pageSize = 100
 
+// Method for the feemarket module's base fee query. Evmos' generated feemarket types do not build against the SDK version in use, so
+// the query is invoked directly. QueryBaseFeeRequest is empty and QueryBaseFeeResponse is a single string field, so well known types are
+// wire compatible.
+const baseFeeMethod = "/ethermint.feemarket.v1.Query/BaseFee"
+
 // grpcClient is the private and default implementation.
 type grpcClient struct {
-	cdc *codec.ProtoCodec
+	cdc  *codec.ProtoCodec
+	conn *gogrpc.ClientConn
 
 	authClient         authtypes.QueryClient
 	authzClient        authztypes.QueryClient
 	bankClient         banktypes.QueryClient
 	distributionClient distributiontypes.QueryClient
 	stakingClient      stakingtypes.QueryClient
+	tmClient           tmservice.ServiceClient
 	txClient           txtypes.ServiceClient
 
 	log *log.Logger
@@ -60,16 +71,19 @@ func NewGrpcClient(nodeGrpcUri string, cdc *codec.ProtoCodec, log *log.Logger) (
 	bankClient := banktypes.NewQueryClient(conn)
 	distributionClient := distributiontypes.NewQueryClient(conn)
 	stakingClient := stakingtypes.NewQueryClient(conn)
+	tmClient := tmservice.NewServiceClient(conn)
 	txClient := txtypes.NewServiceClient(conn)
 
 	return &grpcClient{
-		cdc: cdc,
+		cdc:  cdc,
+		conn: conn,
 
 		authClient:         authClient,
 		authzClient:        authzClient,
 		bankClient:         bankClient,
 		distributionClient: distributionClient,
 		stakingClient:      stakingClient,
+		tmClient:           tmClient,
 		txClient:           txClient,
 
 		log: log,
@@ -132,6 +146,27 @@ func (r *grpcClient) GetPendingRewards(ctx context.Context, delegator, validator
 	return sdk.NewDec(0), nil
 }
 
+// GetBaseFee returns the current EIP-1559 base fee from the feemarket module. Chains without a feemarket module will return an error.
+func (r *grpcClient) GetBaseFee(ctx context.Context) (sdk.Dec, error) {
+	response := &wrapperspb.StringValue{}
+	err := r.conn.Invoke(ctx, baseFeeMethod, &emptypb.Empty{}, response)
+	if err != nil {
+		return sdk.NewDec(0), err
+	}
+
+	// An empty base fee indicates that the London hard fork has not been enabled
+	if response.Value == "" {
+		return sdk.NewDec(0), fmt.Errorf("feemarket module returned no base fee")
+	}
+
+	baseFee, ok := sdk.NewIntFromString(response.Value)
+	if !ok {
+		return sdk.NewDec(0), fmt.Errorf("unable to parse base fee: %s", response.Value)
+	}
+
+	return sdk.NewDecFromInt(baseFee), nil
+}
+
 func (r *grpcClient) Broadcast(
 	ctx context.Context,
 	txBytes []byte,
@@ -154,6 +189,51 @@ func (r *grpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.G
 	return r.txClient.GetTx(ctx, request)
 }
 
+func (r *grpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	response, err := r.tmClient.GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	// Prefer the SDK block, which replaces the deprecated Tendermint block
+	if response.SdkBlock != nil {
+		return response.SdkBlock.Header.Height, nil
+	}
+	if response.Block != nil {
+		return response.Block.Header.Height, nil
+	}
+	return 0, fmt.Errorf("latest block response contained no block")
+}
+
+// GetTxsInBlock returns the decoded txs in the block at height.
+func (r *grpcClient) GetTxsInBlock(ctx context.Context, height int64) ([]*txtypes.Tx, error) {
+	// GetBlockWithTxs paginates by offset, rather than by key, so retrievePaginatedData cannot be used.
+	txs := []*txtypes.Tx{}
+	for {
+		request := &txtypes.GetBlockWithTxsRequest{
+			Height: height,
+			Pagination: &query.PageRequest{
+				Offset:     uint64(len(txs)),
+				Limit:      pageSize,
+				CountTotal: true,
+			},
+		}
+		response, err := r.txClient.GetBlockWithTxs(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, response.Txs...)
+		r.log.Debug("fetched page of txs in block", "height", height, "num in page", len(response.Txs), "total fetched", len(txs))
+
+		if len(response.Txs) == 0 || response.Pagination == nil || uint64(len(txs)) >= response.Pagination.Total {
+			break
+		}
+	}
+
+	return txs, nil
+}
+
 func (r *grpcClient) Account(ctx context.Context, address string) (authtypes.AccountI, error) {
 	// Make a query
 	query := &authtypes.QueryAccountRequest{Address: address}
@@ -164,6 +164,30 @@ func (r *retryableRpcClient) GetBalance(ctx context.Context, address, denom stri
 	return result, nil
 }
 
+func (r *retryableRpcClient) GetBaseFee(ctx context.Context) (sdk.Dec, error) {
+	var result sdk.Dec
+	var err error
+
+	err = retry.Do(func() error {
+		result, err = r.wrappedClient.GetBaseFee(ctx)
+		if err != nil {
+			r.logger.Error("failed call in rpc client, will retry", "error", err.Error(), "method", "base_fee")
+		}
+		return err
+	}, r.delay, r.attempts, retry.Context(ctx))
+	if err != nil {
+		// If err is an error from a context, unwrapping will write out nil
+		unwrappedErr := errors.Unwrap(err)
+		if unwrappedErr != nil {
+			return result, unwrappedErr
+		} else {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
 func (r *retryableRpcClient) GetDenomMetadata(ctx context.Context, denom string) (*banktypes.Metadata, error) {
 	var result *banktypes.Metadata
 	var err error
@@ -259,3 +283,51 @@ func (r *retryableRpcClient) GetPendingRewards(ctx context.Context, delegator, v
 
 	return result, nil
 }
+
+func (r *retryableRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	var result int64
+	var err error
+
+	err = retry.Do(func() error {
+		result, err = r.wrappedClient.GetLatestBlockHeight(ctx)
+		if err != nil {
+			r.logger.Error("failed call in rpc client, will retry", "error", err.Error(), "method", "latest_block_height")
+		}
+		return err
+	}, r.delay, r.attempts, retry.Context(ctx))
+	if err != nil {
+		// If err is an error from a context, unwrapping will write out nil
+		unwrappedErr := errors.Unwrap(err)
+		if unwrappedErr != nil {
+			return 0, unwrappedErr
+		} else {
+			return 0, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *retryableRpcClient) GetTxsInBlock(ctx context.Context, height int64) ([]*txtypes.Tx, error) {
+	var result []*txtypes.Tx
+	var err error
+
+	err = retry.Do(func() error {
+		result, err = r.wrappedClient.GetTxsInBlock(ctx, height)
+		if err != nil {
+			r.logger.Error("failed call in rpc client, will retry", "error", err.Error(), "method", "txs_in_block")
+		}
+		return err
+	}, r.delay, r.attempts, retry.Context(ctx))
+	if err != nil {
+		// If err is an error from a context, unwrapping will write out nil
+		unwrappedErr := errors.Unwrap(err)
+		if unwrappedErr != nil {
+			return nil, unwrappedErr
+		} else {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
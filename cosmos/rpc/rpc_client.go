@@ -19,9 +19,12 @@ type RpcClient interface {
 	Account(ctx context.Context, address string) (authtypes.AccountI, error)
 
 	GetBalance(ctx context.Context, address, denom string) (*sdk.Coin, error)
+	GetBaseFee(ctx context.Context) (sdk.Dec, error)
 	GetDelegators(ctx context.Context, validatorAddress string) ([]string, error)
 	GetDenomMetadata(ctx context.Context, denom string) (*banktypes.Metadata, error)
 	GetGrants(ctx context.Context, botAddress string) ([]*authztypes.GrantAuthorization, error)
+	GetLatestBlockHeight(ctx context.Context) (int64, error)
 	GetPendingRewards(ctx context.Context, delegator, validator, stakingDenom string) (sdk.Dec, error)
 	GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error)
+	GetTxsInBlock(ctx context.Context, height int64) ([]*txtypes.Tx, error)
 }
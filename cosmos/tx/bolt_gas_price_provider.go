@@ -0,0 +1,564 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltGasDataFile is the database file name inside the data directory
+const boltGasDataFile = "gas_prices.db"
+
+// How long to wait for another process to release the database
+const boltOpenTimeout = 5 * time.Second
+
+// Buckets in the database
+var (
+	gasPricesBucket       = []byte("gas_prices")
+	gasFactorsBucket      = []byte("gas_factors")
+	gasFactorStatesBucket = []byte("gas_factor_states")
+	gasStreaksBucket      = []byte("gas_streaks")
+	gasHistoryBucket      = []byte("gas_history")
+
+	// When prices and factors were last set
+	gasPricesUpdatedAtBucket  = []byte("gas_prices_updated_at")
+	gasFactorsUpdatedAtBucket = []byte("gas_factors_updated_at")
+)
+
+// Separates chain names from denoms in keys. Denoms may contain '/', so a null byte is used.
+const boltKeySeparator = byte(0)
+
+// boltGasPriceProvider stores gas prices in an embedded bbolt database, along with a history of every change.
+//
+// Current values are keyed by chain name and denom. History entries are keyed by time, so that queries over a time range are cheap.
+type boltGasPriceProvider struct {
+	db *bolt.DB
+
+	// How long to keep history for. Zero keeps history forever.
+	historyRetention time.Duration
+
+	logger *log.Logger
+}
+
+// BoltGasPriceProviderOption configures a bolt gas price provider.
+type BoltGasPriceProviderOption func(*boltGasPriceProvider)
+
+// WithGasHistoryRetention drops history entries older than the retention whenever a price or factor changes.
+func WithGasHistoryRetention(retention time.Duration) BoltGasPriceProviderOption {
+	return func(p *boltGasPriceProvider) {
+		p.historyRetention = retention
+	}
+}
+
+// Assert all boltGasPriceProviders are GasHistoryProviders
+var _ GasHistoryProvider = (*boltGasPriceProvider)(nil)
+
+// NewBoltGasPriceProvider opens, or creates, a gas price database in the data directory.
+func NewBoltGasPriceProvider(logger *log.Logger, dataDirectory string, opts ...BoltGasPriceProviderOption) (GasHistoryProvider, error) {
+	file := fmt.Sprintf("%s/%s", dataDirectory, boltGasDataFile)
+
+	db, err := bolt.Open(file, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open gas price database %s: %w", file, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{gasPricesBucket, gasFactorsBucket, gasFactorStatesBucket, gasStreaksBucket, gasHistoryBucket, gasPricesUpdatedAtBucket, gasFactorsUpdatedAtBucket} {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	logger.Info("💾 opened gas price database", "file", file)
+
+	provider := &boltGasPriceProvider{
+		db:     db,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(provider)
+	}
+	return provider, nil
+}
+
+func (p *boltGasPriceProvider) HasGasPrice(chainName, denom string) (bool, error) {
+	_, err := p.GetGasPrice(chainName, denom)
+	if err == ErrNoGasPrice {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *boltGasPriceProvider) GetGasPrice(chainName, denom string) (float64, error) {
+	gasPrice, found, err := p.getFloat(gasPricesBucket, chainName, denom)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrNoGasPrice
+	}
+	return gasPrice, nil
+}
+
+func (p *boltGasPriceProvider) SetGasPrice(chainName, denom string, gasPrice float64) error {
+	return p.SetGasPriceWithReason(chainName, denom, gasPrice, "")
+}
+
+func (p *boltGasPriceProvider) SetGasPriceWithReason(chainName, denom string, gasPrice float64, reason string) error {
+	return p.setFloat(gasPricesBucket, GasPriceChange, chainName, denom, gasPrice, time.Now().UTC(), reason)
+}
+
+func (p *boltGasPriceProvider) setGasPriceAt(chainName, denom string, gasPrice float64, updatedAt time.Time, reason string) error {
+	return p.setFloat(gasPricesBucket, GasPriceChange, chainName, denom, gasPrice, updatedAt, reason)
+}
+
+func (p *boltGasPriceProvider) HasGasFactor(chainName, denom string) (bool, error) {
+	_, err := p.GetGasFactor(chainName, denom)
+	if err == ErrNoGasFactor {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *boltGasPriceProvider) GetGasFactor(chainName, denom string) (float64, error) {
+	gasFactor, found, err := p.getFloat(gasFactorsBucket, chainName, denom)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrNoGasFactor
+	}
+	return gasFactor, nil
+}
+
+func (p *boltGasPriceProvider) SetGasFactor(chainName, denom string, gasFactor float64) error {
+	return p.SetGasFactorWithReason(chainName, denom, gasFactor, "")
+}
+
+func (p *boltGasPriceProvider) SetGasFactorWithReason(chainName, denom string, gasFactor float64, reason string) error {
+	return p.setFloat(gasFactorsBucket, GasFactorChange, chainName, denom, gasFactor, time.Now().UTC(), reason)
+}
+
+func (p *boltGasPriceProvider) setGasFactorAt(chainName, denom string, gasFactor float64, updatedAt time.Time, reason string) error {
+	return p.setFloat(gasFactorsBucket, GasFactorChange, chainName, denom, gasFactor, updatedAt, reason)
+}
+
+func (p *boltGasPriceProvider) GetGasFactorState(chainName, denom string) (*GasFactorState, error) {
+	gasFactorState := &GasFactorState{}
+	found, err := p.getJSON(gasFactorStatesBucket, chainName, denom, gasFactorState)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNoGasFactorState
+	}
+	return gasFactorState, nil
+}
+
+func (p *boltGasPriceProvider) SetGasFactorState(chainName, denom string, gasFactorState *GasFactorState) error {
+	return p.setJSON(gasFactorStatesBucket, chainName, denom, gasFactorState)
+}
+
+func (p *boltGasPriceProvider) GetGasStreak(chainName, denom string) (*GasStreak, error) {
+	gasStreak := &GasStreak{}
+	found, err := p.getJSON(gasStreaksBucket, chainName, denom, gasStreak)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNoGasStreak
+	}
+	return gasStreak, nil
+}
+
+func (p *boltGasPriceProvider) SetGasStreak(chainName, denom string, gasStreak *GasStreak) error {
+	return p.setJSON(gasStreaksBucket, chainName, denom, gasStreak)
+}
+
+func (p *boltGasPriceProvider) ResetGas(chainName string) error {
+	prefix := boltKey(chainName, "")
+
+	return p.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{gasPricesBucket, gasPricesUpdatedAtBucket, gasFactorsBucket, gasFactorsUpdatedAtBucket, gasFactorStatesBucket, gasStreaksBucket} {
+			// Collect keys first, since deleting while iterating a cursor skips keys
+			keys := [][]byte{}
+			cursor := tx.Bucket(bucket).Cursor()
+			for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+				keys = append(keys, key)
+			}
+
+			for _, key := range keys {
+				err := tx.Bucket(bucket).Delete(key)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (p *boltGasPriceProvider) getGasData() (*GasData, error) {
+	gasData := emptyGasData()
+	err := p.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(gasPricesBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+			setByChainAndDenom(gasData.GasPrices, chainName, denom, decodeFloat(value))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(gasFactorsBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+			setByChainAndDenom(gasData.GasFactors, chainName, denom, decodeFloat(value))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(gasFactorStatesBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+
+			gasFactorState := GasFactorState{}
+			err := json.Unmarshal(value, &gasFactorState)
+			if err != nil {
+				return err
+			}
+			setByChainAndDenom(gasData.GasFactorStates, chainName, denom, gasFactorState)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(gasStreaksBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+
+			gasStreak := GasStreak{}
+			err := json.Unmarshal(value, &gasStreak)
+			if err != nil {
+				return err
+			}
+			setByChainAndDenom(gasData.GasStreaks, chainName, denom, gasStreak)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(gasPricesUpdatedAtBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+			setByChainAndDenom(gasData.GasPricesUpdatedAt, chainName, denom, decodeTime(value))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(gasFactorsUpdatedAtBucket).ForEach(func(key, value []byte) error {
+			chainName, denom := splitBoltKey(key)
+			setByChainAndDenom(gasData.GasFactorsUpdatedAt, chainName, denom, decodeTime(value))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gasData, nil
+}
+
+// History
+
+func (p *boltGasPriceProvider) GetGasHistory(query GasHistoryQuery) ([]GasChange, error) {
+	changes := []GasChange{}
+	err := p.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(gasHistoryBucket).Cursor()
+
+		// With a limit, walk backwards from the end of the range so that the most recent changes are kept.
+		if query.Limit > 0 {
+			key, value := seekBefore(cursor, query.Until)
+			for ; key != nil && len(changes) < query.Limit; key, value = cursor.Prev() {
+				change, err := decodeGasChange(value)
+				if err != nil {
+					return err
+				}
+				if !query.Since.IsZero() && change.Timestamp.Before(query.Since) {
+					break
+				}
+				if query.Matches(change) {
+					changes = append(changes, *change)
+				}
+			}
+
+			// Return oldest first
+			for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+				changes[i], changes[j] = changes[j], changes[i]
+			}
+			return nil
+		}
+
+		key, value := cursor.First()
+		if !query.Since.IsZero() {
+			key, value = cursor.Seek(historyKeyPrefix(query.Since))
+		}
+		for ; key != nil; key, value = cursor.Next() {
+			change, err := decodeGasChange(value)
+			if err != nil {
+				return err
+			}
+			if !query.Until.IsZero() && !change.Timestamp.Before(query.Until) {
+				break
+			}
+			if query.Matches(change) {
+				changes = append(changes, *change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (p *boltGasPriceProvider) GetLatestGasChange(query GasHistoryQuery) (*GasChange, error) {
+	query.Limit = 1
+	changes, err := p.GetGasHistory(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return &changes[0], nil
+}
+
+func (p *boltGasPriceProvider) PruneGasHistory(before time.Time) (int, error) {
+	pruned := 0
+	err := p.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		pruned, err = pruneHistory(tx.Bucket(gasHistoryBucket), before)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	p.logger.Debug("pruned gas history", "entries", pruned, "before", before)
+	return pruned, nil
+}
+
+func (p *boltGasPriceProvider) Close() error {
+	return p.db.Close()
+}
+
+// Helpers - storage
+
+func (p *boltGasPriceProvider) getFloat(bucket []byte, chainName, denom string) (float64, bool, error) {
+	var result float64
+	var found bool
+	err := p.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucket).Get(boltKey(chainName, denom))
+		if value == nil {
+			return nil
+		}
+
+		result = decodeFloat(value)
+		found = true
+		return nil
+	})
+	return result, found, err
+}
+
+// Unmarshal a JSON value into result. Returns whether a value was found.
+func (p *boltGasPriceProvider) getJSON(bucket []byte, chainName, denom string, result any) (bool, error) {
+	var found bool
+	err := p.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucket).Get(boltKey(chainName, denom))
+		if value == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(value, result)
+	})
+	return found, err
+}
+
+func (p *boltGasPriceProvider) setJSON(bucket []byte, chainName, denom string, value any) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(boltKey(chainName, denom), valueBytes)
+	})
+}
+
+// Set a value and record the change in history, in a single transaction. The value is marked as updated at updatedAt, or left without a
+// timestamp if updatedAt is zero. History records when the change was made to this database.
+func (p *boltGasPriceProvider) setFloat(bucket []byte, kind, chainName, denom string, newValue float64, updatedAt time.Time, reason string) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		key := boltKey(chainName, denom)
+		values := tx.Bucket(bucket)
+
+		oldValue := 0.0
+		if existing := values.Get(key); existing != nil {
+			oldValue = decodeFloat(existing)
+		}
+
+		err := values.Put(key, encodeFloat(newValue))
+		if err != nil {
+			return err
+		}
+
+		if updatedAt.IsZero() {
+			err = tx.Bucket(updatedAtBucket(bucket)).Delete(key)
+		} else {
+			err = tx.Bucket(updatedAtBucket(bucket)).Put(key, encodeTime(updatedAt))
+		}
+		if err != nil {
+			return err
+		}
+
+		history := tx.Bucket(gasHistoryBucket)
+		sequence, err := history.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		timestamp := time.Now().UTC()
+		change := &GasChange{
+			Timestamp: timestamp,
+			ChainName: chainName,
+			Denom:     denom,
+			Kind:      kind,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			Reason:    reason,
+		}
+		changeBytes, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+
+		err = history.Put(historyKey(change.Timestamp, sequence), changeBytes)
+		if err != nil {
+			return err
+		}
+
+		if p.historyRetention > 0 {
+			_, err = pruneHistory(history, timestamp.Add(-p.historyRetention))
+		}
+		return err
+	})
+}
+
+// Delete history entries before a time. History keys are ordered by time, so only the oldest entries are visited.
+func pruneHistory(history *bolt.Bucket, before time.Time) (int, error) {
+	cutoff := historyKeyPrefix(before)
+
+	// Collect keys first, since deleting while iterating skips entries
+	keys := [][]byte{}
+	cursor := history.Cursor()
+	for key, _ := cursor.First(); key != nil && bytes.Compare(key, cutoff) < 0; key, _ = cursor.Next() {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		err := history.Delete(key)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// Position a cursor on the last entry before a time, or on the last entry if the time is zero.
+func seekBefore(cursor *bolt.Cursor, until time.Time) ([]byte, []byte) {
+	if until.IsZero() {
+		return cursor.Last()
+	}
+
+	key, _ := cursor.Seek(historyKeyPrefix(until))
+	if key == nil {
+		return cursor.Last()
+	}
+	return cursor.Prev()
+}
+
+func boltKey(chainName, denom string) []byte {
+	key := make([]byte, 0, len(chainName)+len(denom)+1)
+	key = append(key, chainName...)
+	key = append(key, boltKeySeparator)
+	return append(key, denom...)
+}
+
+func splitBoltKey(key []byte) (string, string) {
+	chainName, denom, _ := bytes.Cut(key, []byte{boltKeySeparator})
+	return string(chainName), string(denom)
+}
+
+// History keys are a big endian timestamp, followed by a sequence number to order changes in the same nanosecond.
+func historyKey(timestamp time.Time, sequence uint64) []byte {
+	key := historyKeyPrefix(timestamp)
+	return binary.BigEndian.AppendUint64(key, sequence)
+}
+
+func historyKeyPrefix(timestamp time.Time) []byte {
+	return binary.BigEndian.AppendUint64(make([]byte, 0, 16), uint64(timestamp.UnixNano()))
+}
+
+func decodeGasChange(value []byte) (*GasChange, error) {
+	change := &GasChange{}
+	err := json.Unmarshal(value, change)
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// Get the bucket recording when values in a bucket were last set.
+func updatedAtBucket(bucket []byte) []byte {
+	if bytes.Equal(bucket, gasPricesBucket) {
+		return gasPricesUpdatedAtBucket
+	}
+	return gasFactorsUpdatedAtBucket
+}
+
+func encodeTime(value time.Time) []byte {
+	return binary.BigEndian.AppendUint64(make([]byte, 0, 8), uint64(value.UnixNano()))
+}
+
+func decodeTime(value []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(value))).UTC()
+}
+
+func encodeFloat(value float64) []byte {
+	return binary.BigEndian.AppendUint64(make([]byte, 0, 8), math.Float64bits(value))
+}
+
+func decodeFloat(value []byte) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(value))
+}
@@ -0,0 +1,117 @@
+package tx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+func TestBoltGasPriceProvider_PersistsValues(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	provider, err := tx.NewBoltGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	require.NoError(t, provider.SetGasPrice("cosmoshub", "uatom", 0.025))
+	require.NoError(t, provider.SetGasFactor("cosmoshub", "uatom", 1.2))
+	require.NoError(t, provider.SetGasFactorState("cosmoshub", "uatom", &tx.GasFactorState{IsTryingToStepDown: true, SuccessThreshold: 20}))
+	require.NoError(t, provider.Close())
+
+	reopened, err := tx.NewBoltGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	gasPrice, err := reopened.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+
+	gasFactor, err := reopened.GetGasFactor("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 1.2, gasFactor)
+
+	state, err := reopened.GetGasFactorState("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 20, state.SuccessThreshold)
+
+	_, err = reopened.GetGasPrice("cosmoshub", "ibc/ABC")
+	require.ErrorIs(t, err, tx.ErrNoGasPrice)
+}
+
+func TestBoltGasPriceProvider_RecordsReasonsFromGasManager(t *testing.T) {
+	provider, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer provider.Close()
+
+	gasManager, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default())
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, gasManager.InitializePrice("cosmoshub", "uatom", 0.1))
+	require.NoError(t, gasManager.ManageInclusionFailure("cosmoshub", "uatom"))
+	require.NoError(t, gasManager.InitializePrice("osmosis", "uosmo", 0.1))
+
+	history, err := provider.GetGasHistory(tx.GasHistoryQuery{ChainName: "cosmoshub", Kind: tx.GasPriceChange, Since: start})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.Equal(t, "initialized", history[0].Reason)
+	require.Equal(t, 0.0, history[0].OldValue)
+	require.Equal(t, 0.1, history[0].NewValue)
+
+	require.Equal(t, 0.1, history[1].OldValue)
+	require.Greater(t, history[1].NewValue, 0.1)
+	require.Contains(t, history[1].Reason, "1 consecutive failures")
+	require.Contains(t, history[1].Reason, "tx was not included")
+
+	latest, err := provider.GetLatestGasChange(tx.GasHistoryQuery{Kind: tx.GasPriceChange})
+	require.NoError(t, err)
+	require.Equal(t, "osmosis", latest.ChainName)
+
+	// Nothing has happened since the last change
+	none, err := provider.GetGasHistory(tx.GasHistoryQuery{Since: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
+
+func TestBoltGasPriceProvider_PrunesGasHistory(t *testing.T) {
+	provider, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer provider.Close()
+
+	require.NoError(t, provider.SetGasPriceWithReason("cosmoshub", "uatom", 0.01, "first"))
+	require.NoError(t, provider.SetGasPriceWithReason("cosmoshub", "uatom", 0.02, "second"))
+	cutoff := time.Now()
+	require.NoError(t, provider.SetGasPriceWithReason("cosmoshub", "uatom", 0.03, "third"))
+
+	pruned, err := provider.PruneGasHistory(cutoff)
+	require.NoError(t, err)
+	require.Equal(t, 2, pruned)
+
+	history, err := provider.GetGasHistory(tx.GasHistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "third", history[0].Reason)
+
+	// Current values are kept
+	gasPrice, err := provider.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.03, gasPrice)
+}
+
+func TestBoltGasPriceProvider_GasHistoryRetention(t *testing.T) {
+	provider, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir(), tx.WithGasHistoryRetention(50*time.Millisecond))
+	require.NoError(t, err)
+	defer provider.Close()
+
+	require.NoError(t, provider.SetGasPriceWithReason("cosmoshub", "uatom", 0.01, "old"))
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, provider.SetGasPriceWithReason("cosmoshub", "uatom", 0.02, "new"))
+
+	history, err := provider.GetGasHistory(tx.GasHistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "new", history[0].Reason)
+}
@@ -0,0 +1,170 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// TxEvent describes a tx at a stage of broadcasting. Fields which are not known at a stage are left empty.
+type TxEvent struct {
+	ChainName     string
+	TxHash        string
+	Sequence      uint64
+	TimeoutHeight uint64
+
+	// The fee and gas the tx was signed with
+	Fee       sdk.Coin
+	GasPrice  float64
+	GasFactor float64
+	GasWanted int64
+
+	// Set once the tx is included
+	GasUsed int64
+	Height  int64
+
+	// Set when gas is adjusted. Values which were not adjusted are left empty.
+	AdjustedGasPrice  float64
+	AdjustedGasFactor float64
+
+	// What went wrong, if anything, and the kind of ABCI error it is, such as abci.ErrOutOfGas. ErrKind is nil for errors which are not
+	// recognized ABCI errors.
+	Err     error
+	ErrKind error
+}
+
+// BroadcastHooks are notified as txs move through a Broadcaster, for instance to send alerts, update metrics or write audit records.
+//
+// Hooks are called synchronously from the broadcasting goroutine, so they should return quickly. Embed NoopBroadcastHooks to only implement
+// some of the callbacks.
+type BroadcastHooks interface {
+	// A tx was signed and is about to be broadcast.
+	TxSigned(ctx context.Context, event TxEvent)
+
+	// A tx passed CheckTx and is in the mempool.
+	BroadcastAccepted(ctx context.Context, event TxEvent)
+
+	// A tx failed CheckTx, or could not be broadcast.
+	BroadcastRejected(ctx context.Context, event TxEvent)
+
+	// A tx landed on chain and succeeded.
+	TxIncluded(ctx context.Context, event TxEvent)
+
+	// A tx landed on chain but failed.
+	TxFailed(ctx context.Context, event TxEvent)
+
+	// A tx was not found after polling for inclusion.
+	TxNotFound(ctx context.Context, event TxEvent)
+
+	// The gas price or gas factor changed because of a tx's result. Only reported by the gas managers in this package, which know the changes
+	// they make.
+	GasAdjusted(ctx context.Context, event TxEvent)
+
+	// Msgs will be sent again in a new tx, because of a tx's result.
+	RetryScheduled(ctx context.Context, event TxEvent)
+}
+
+// NoopBroadcastHooks ignores every callback.
+type NoopBroadcastHooks struct{}
+
+var _ BroadcastHooks = NoopBroadcastHooks{}
+
+func (NoopBroadcastHooks) TxSigned(ctx context.Context, event TxEvent)          {}
+func (NoopBroadcastHooks) BroadcastAccepted(ctx context.Context, event TxEvent) {}
+func (NoopBroadcastHooks) BroadcastRejected(ctx context.Context, event TxEvent) {}
+func (NoopBroadcastHooks) TxIncluded(ctx context.Context, event TxEvent)        {}
+func (NoopBroadcastHooks) TxFailed(ctx context.Context, event TxEvent)          {}
+func (NoopBroadcastHooks) TxNotFound(ctx context.Context, event TxEvent)        {}
+func (NoopBroadcastHooks) GasAdjusted(ctx context.Context, event TxEvent)       {}
+func (NoopBroadcastHooks) RetryScheduled(ctx context.Context, event TxEvent)    {}
+
+// WithHooks notifies hooks as txs are broadcast. May be given more than once, and hooks are called in the order they were given.
+func WithHooks(hooks ...BroadcastHooks) TxBroadcasterOption {
+	return func(b *defaultBroadcaster) {
+		b.hooks.hooks = append(b.hooks.hooks, hooks...)
+	}
+}
+
+// Registered hooks, shared by the layers of a broadcaster. Signed txs are remembered by hash, so that later stages can report the fee
+// and gas a tx was signed with.
+type txHooks struct {
+	chainName string
+	hooks     []BroadcastHooks
+
+	txs  map[string]TxEvent
+	lock *sync.Mutex
+}
+
+func newTxHooks(chainName string) *txHooks {
+	return &txHooks{
+		chainName: chainName,
+		hooks:     []BroadcastHooks{},
+
+		txs:  make(map[string]TxEvent),
+		lock: &sync.Mutex{},
+	}
+}
+
+// Whether any hooks were given.
+func (h *txHooks) registered() bool {
+	return len(h.hooks) > 0
+}
+
+// Remember a signed tx.
+func (h *txHooks) record(event TxEvent) {
+	if !h.registered() {
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.txs[event.TxHash] = event
+}
+
+// Get an event for txHash, filled in with what is known about the tx.
+func (h *txHooks) event(txHash string, err error) TxEvent {
+	h.lock.Lock()
+	event, found := h.txs[txHash]
+	h.lock.Unlock()
+
+	if !found {
+		event = TxEvent{
+			ChainName: h.chainName,
+			TxHash:    txHash,
+		}
+	}
+	event.Err = err
+	event.ErrKind = abciErrorKind(err)
+	return event
+}
+
+// Stop remembering txs.
+func (h *txHooks) forget(txHashes ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, txHash := range txHashes {
+		delete(h.txs, txHash)
+	}
+}
+
+// Call notify with each hook.
+func (h *txHooks) notify(notify func(hooks BroadcastHooks)) {
+	for _, hooks := range h.hooks {
+		notify(hooks)
+	}
+}
+
+// Get the kind of a recognized ABCI error, or nil.
+func abciErrorKind(err error) error {
+	var abciErr *abci.Error
+	if errors.As(err, &abciErr) {
+		return abciErr.Kind
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package tx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// outOfGasRpcClient rejects the first tx for running out of gas, and accepts and includes the rest.
+type outOfGasRpcClient struct {
+	*sequenceRpcClient
+
+	broadcasts int
+}
+
+func (c *outOfGasRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	c.broadcasts++
+	if c.broadcasts == 1 {
+		return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: tx.TxHash(txBytes), Codespace: "sdk", Code: 11, RawLog: "out of gas"}}, nil
+	}
+	return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: tx.TxHash(txBytes)}}, nil
+}
+
+func (c *outOfGasRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	return &txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: txHash, Height: 7, GasWanted: 100, GasUsed: 90}}, nil
+}
+
+// A callback a hook received
+type hookCall struct {
+	stage string
+	event tx.TxEvent
+}
+
+// recordingHooks records the callbacks it receives.
+type recordingHooks struct {
+	calls []hookCall
+}
+
+func (h *recordingHooks) record(stage string, event tx.TxEvent) {
+	h.calls = append(h.calls, hookCall{stage: stage, event: event})
+}
+
+func (h *recordingHooks) stages() []string {
+	stages := []string{}
+	for _, call := range h.calls {
+		stages = append(stages, call.stage)
+	}
+	return stages
+}
+
+func (h *recordingHooks) TxSigned(ctx context.Context, event tx.TxEvent) { h.record("signed", event) }
+func (h *recordingHooks) BroadcastAccepted(ctx context.Context, event tx.TxEvent) {
+	h.record("accepted", event)
+}
+func (h *recordingHooks) BroadcastRejected(ctx context.Context, event tx.TxEvent) {
+	h.record("rejected", event)
+}
+func (h *recordingHooks) TxIncluded(ctx context.Context, event tx.TxEvent) {
+	h.record("included", event)
+}
+func (h *recordingHooks) TxFailed(ctx context.Context, event tx.TxEvent) { h.record("failed", event) }
+func (h *recordingHooks) TxNotFound(ctx context.Context, event tx.TxEvent) {
+	h.record("not_found", event)
+}
+func (h *recordingHooks) GasAdjusted(ctx context.Context, event tx.TxEvent) {
+	h.record("gas_adjusted", event)
+}
+func (h *recordingHooks) RetryScheduled(ctx context.Context, event tx.TxEvent) {
+	h.record("retry_scheduled", event)
+}
+
+func TestBroadcaster_NotifiesHooks(t *testing.T) {
+	rpcClient := &outOfGasRpcClient{sequenceRpcClient: &sequenceRpcClient{}}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	hooks := &recordingHooks{}
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithHooks(hooks),
+	)
+	require.NoError(t, err)
+
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"signed", "rejected", "gas_adjusted", "retry_scheduled",
+		"signed", "accepted", "included",
+	}, hooks.stages())
+
+	// Rejections carry the tx's fee and the kind of error
+	rejected := hooks.calls[1].event
+	require.Equal(t, "chain", rejected.ChainName)
+	require.Equal(t, tx.TxHash([]byte("0")), rejected.TxHash)
+	require.Equal(t, sdk.NewInt64Coin("ufoo", 2), rejected.Fee)
+	require.Equal(t, int64(100), rejected.GasWanted)
+	require.ErrorIs(t, rejected.Err, abci.ErrOutOfGas)
+	require.Equal(t, abci.ErrOutOfGas, rejected.ErrKind)
+
+	// Gas adjustments carry the new gas factor
+	adjusted := hooks.calls[2].event
+	require.Greater(t, adjusted.AdjustedGasFactor, adjusted.GasFactor)
+
+	// Inclusions carry the gas used
+	included := hooks.calls[6].event
+	require.Equal(t, txHash, included.TxHash)
+	require.Equal(t, int64(90), included.GasUsed)
+	require.Equal(t, int64(7), included.Height)
+	require.NoError(t, included.Err)
+	require.Nil(t, included.ErrKind)
+}
+
+// driftingBaseFeeRpcClient includes every tx, and serves a base fee which rises with every query.
+type driftingBaseFeeRpcClient struct {
+	*sequenceRpcClient
+
+	queries int
+}
+
+func (c *driftingBaseFeeRpcClient) GetBaseFee(ctx context.Context) (sdk.Dec, error) {
+	c.queries++
+	return sdk.NewDec(int64(c.queries)), nil
+}
+
+func TestBroadcaster_ReportsGasAdjustmentsFromGasManager(t *testing.T) {
+	rpcClient := &driftingBaseFeeRpcClient{sequenceRpcClient: &sequenceRpcClient{}}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	gasManager, err := tx.NewFeeMarketGasManager("chain", 1, 0, 0, rpcClient, newBacktestGasManager(t, 0.01), nil, log.Default())
+	require.NoError(t, err)
+
+	hooks := &recordingHooks{}
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider, &sequenceTxProvider{},
+		1, time.Millisecond, 1, time.Millisecond, tx.WithHooks(hooks),
+	)
+	require.NoError(t, err)
+
+	// The base fee drifted, but feedback did not change gas, so nothing is reported and the base fee is only queried to sign
+	_, err = broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"signed", "accepted", "included"}, hooks.stages())
+	require.Equal(t, 1, rpcClient.queries)
+}
@@ -3,6 +3,20 @@ package tx
 import "errors"
 
 var (
-	ErrNoGasPrice  = errors.New("no known gas price")
-	ErrNoGasFactor = errors.New("no known gas factor")
+	ErrNoGasPrice       = errors.New("no known gas price")
+	ErrNoGasFactor      = errors.New("no known gas factor")
+	ErrNoGasFactorState = errors.New("no known gas factor state")
+	ErrNoGasStreak      = errors.New("no known gas streak")
+
+	ErrUnrecognizedFeeError = errors.New("unrecognized fee error format")
+	ErrGasPriceOutOfBounds  = errors.New("gas price out of bounds")
+
+	ErrNoTimeoutHeight = errors.New("tx has no timeout height")
+	ErrTxTimedOut      = errors.New("tx passed its timeout height without landing")
+	ErrTxSequenceUsed  = errors.New("tx's sequence was used by another tx")
+	ErrNoFundedSigner  = errors.New("no account in the signer pool has a sufficient balance")
+
+	ErrMsgQueueClosed     = errors.New("message queue is closed")
+	ErrSubmissionExpired  = errors.New("submission was not sent before its deadline")
+	ErrEmptyMsgSubmission = errors.New("submission has no messages")
 )
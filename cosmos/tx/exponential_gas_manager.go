@@ -1,10 +1,12 @@
 package tx
 
 import (
+	"errors"
 	"fmt"
-	"math"
 	"sync"
 
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+	registry "github.com/tessellated-io/pickaxe/cosmos/chain-registry"
 	"github.com/tessellated-io/pickaxe/log"
 
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
@@ -13,36 +15,136 @@ import (
 // Default gas factor to use
 const defaultGasFactor float64 = 1.1
 
+// Defaults for gas factor adjustments
+const (
+	defaultFactorStepSize            float64 = 0.01
+	defaultFactorSuccessThreshold    int     = 10
+	defaultMaxFactorSuccessThreshold int     = 100
+)
+
+// gasKey identifies a chain, and the denom fees are paid in on it.
+type gasKey struct {
+	chainName string
+	denom     string
+}
+
 // Gas manager using exponential backoff.
 //
-// Rough algorithm:
-//   - Given some number of consecutive successes, decrement price by a step size.
-//     Formula: price_new = price_old - step_size
-//   - Given a failure, increase step sizes exponentially.
-//     Formula: price_new = price_old - (step_size * (1 + scale_factor)^(consecutive_failures))
-//
-// / 	 bounded to maxStepSize
+// Gas prices are adjusted by a GasPriceStrategy, which can be set per chain. By default, all chains use the geometric strategy, parameterized
+// with the step size, max step size and scale factor the manager was created with. See geometricGasPriceStrategy. Chains using the fixed
+// strategy are always priced at the fixed price, unless an operator has pinned a price.
 type geometricGasManager struct {
 	// Parameters
-	stepSize    float64
-	maxStepSize float64
-	scaleFactor float64
+	defaultPriceStrategy GasPriceStrategy
+	priceStrategies      map[string]GasPriceStrategy
+	priceBounds          map[gasKey]*GasPriceBounds
 
-	// State
-	consecutiveGasPriceSuccesses map[string]int
-	consecutiveGasPriceFailures  map[string]int
+	// Chains whose fee tokens bound prices, unless bounds are set explicitly, and overrides applied to the fee tokens' bounds
+	registryChains  []*registry.ChainInfo
+	boundsOverrides map[gasKey]*GasPriceBoundsConfig
 
-	consecutiveGasFactorSuccesses map[string]int
-	consecutiveGasFactorFailures  map[string]int
+	factorStepSize            float64
+	factorSuccessThreshold    int
+	maxFactorSuccessThreshold int
 
+	// State. Streaks of outcomes are kept in the gas price provider, so that they survive restarts.
 	lock *sync.Mutex
 
+	// Prices requested with InitializePrice, which are used again if a chain's gas is reset
+	initialPrices     map[gasKey]float64
+	initialPricesLock *sync.Mutex
+
+	// Parsers for required fees in failing tx logs
+	feeErrorParsers *FeeErrorParserRegistry
+
+	// Values which operators have pinned, and which are not adjusted. May be nil.
+	pins *GasPins
+
+	// Where feedback records the changes it makes. Nil unless the manager was copied with withAdjustments.
+	adjustments *gasAdjustments
+
 	// Core Services
 	gasPriceProvider GasPriceProvider
 	logger           *log.Logger
 }
 
-var _ GasManager = (*geometricGasManager)(nil)
+var _ adjustmentReportingGasManager = (*geometricGasManager)(nil)
+
+// GeometricGasManagerOption configures optional parameters of a geometric gas manager.
+type GeometricGasManagerOption func(*geometricGasManager)
+
+// WithGasFactorStepSize sets the amount the gas factor is adjusted by in each step.
+func WithGasFactorStepSize(factorStepSize float64) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.factorStepSize = factorStepSize
+	}
+}
+
+// WithGasFactorSuccessThreshold sets the number of consecutive successes before a lower gas factor is attempted.
+func WithGasFactorSuccessThreshold(factorSuccessThreshold int) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.factorSuccessThreshold = factorSuccessThreshold
+	}
+}
+
+// WithMaxGasFactorSuccessThreshold bounds the success threshold, which grows each time a lower gas factor fails.
+func WithMaxGasFactorSuccessThreshold(maxFactorSuccessThreshold int) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.maxFactorSuccessThreshold = maxFactorSuccessThreshold
+	}
+}
+
+// WithDefaultGasPriceStrategy sets the gas price strategy used for chains that do not have a strategy set.
+func WithDefaultGasPriceStrategy(strategy GasPriceStrategy) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.defaultPriceStrategy = strategy
+	}
+}
+
+// WithGasPriceStrategy sets the gas price strategy for a chain.
+func WithGasPriceStrategy(chainName string, strategy GasPriceStrategy) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.priceStrategies[chainName] = strategy
+	}
+}
+
+// WithChainRegistry bounds gas prices for each fee token of the chains to the fee token's prices in the chain registry. Overrides set with
+// WithGasPriceBoundsOverrides are applied on top, and bounds set with WithGasPriceBounds take precedence. See NewGasPriceBounds.
+func WithChainRegistry(chainInfos ...*registry.ChainInfo) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.registryChains = append(g.registryChains, chainInfos...)
+	}
+}
+
+// WithGasPriceBoundsOverrides overrides the min or max gas price for a chain and denom, keeping the chain registry's value for the other. If
+// the chain registry has no fee token for the denom, the overrides are the only bounds.
+func WithGasPriceBoundsOverrides(chainName, denom string, overrides *GasPriceBoundsConfig) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.boundsOverrides[gasKey{chainName: chainName, denom: denom}] = overrides
+	}
+}
+
+// WithGasPriceBounds sets the lowest and highest gas prices for a chain and denom, replacing any from the chain registry. See
+// NewGasPriceBounds.
+func WithGasPriceBounds(chainName, denom string, bounds *GasPriceBounds) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.priceBounds[gasKey{chainName: chainName, denom: denom}] = bounds
+	}
+}
+
+// WithFeeErrorParserRegistry sets the parsers used to extract required fees from failing tx logs.
+func WithFeeErrorParserRegistry(feeErrorParsers *FeeErrorParserRegistry) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.feeErrorParsers = feeErrorParsers
+	}
+}
+
+// WithGasPins prevents pinned gas prices and factors from being adjusted. See NewGasAdminHandler.
+func WithGasPins(pins *GasPins) GeometricGasManagerOption {
+	return func(g *geometricGasManager) {
+		g.pins = pins
+	}
+}
 
 func NewGeometricGasManager(
 	stepSize float64,
@@ -50,70 +152,138 @@ func NewGeometricGasManager(
 	scaleFactor float64,
 	gasPriceProvider GasPriceProvider,
 	logger *log.Logger,
+	opts ...GeometricGasManagerOption,
 ) (GasManager, error) {
-	if scaleFactor < 0 || scaleFactor >= 1 {
-		return nil, fmt.Errorf("invalid scale factor: %f. Must conform to: 0 < scale_factor < 1", scaleFactor)
-	}
 	gasLogger := logger.ApplyPrefix("⛽️")
 
+	defaultPriceStrategy, err := NewGeometricGasPriceStrategy(stepSize, maxStepSize, scaleFactor, defaultPriceSuccessThreshold, gasLogger)
+	if err != nil {
+		return nil, err
+	}
+
 	lock := &sync.Mutex{}
 
 	gasManager := &geometricGasManager{
-		stepSize:    stepSize,
-		maxStepSize: maxStepSize,
-		scaleFactor: scaleFactor,
+		defaultPriceStrategy: defaultPriceStrategy,
+		priceStrategies:      make(map[string]GasPriceStrategy),
+		priceBounds:          make(map[gasKey]*GasPriceBounds),
+		boundsOverrides:      make(map[gasKey]*GasPriceBoundsConfig),
+
+		factorStepSize:            defaultFactorStepSize,
+		factorSuccessThreshold:    defaultFactorSuccessThreshold,
+		maxFactorSuccessThreshold: defaultMaxFactorSuccessThreshold,
 
-		consecutiveGasPriceSuccesses: make(map[string]int),
-		consecutiveGasPriceFailures:  make(map[string]int),
+		lock: lock,
 
-		consecutiveGasFactorSuccesses: make(map[string]int),
-		consecutiveGasFactorFailures:  make(map[string]int),
-		lock:                          lock,
+		initialPrices:     make(map[gasKey]float64),
+		initialPricesLock: &sync.Mutex{},
+
+		feeErrorParsers: DefaultFeeErrorParserRegistry(),
 
 		logger:           gasLogger,
 		gasPriceProvider: gasPriceProvider,
 	}
 
+	for _, opt := range opts {
+		opt(gasManager)
+	}
+
+	// Seed bounds from the registry and overrides, without replacing explicit bounds
+	feeTokens := make(map[gasKey]*registry.FeeToken)
+	for _, chainInfo := range gasManager.registryChains {
+		for i := range chainInfo.Fees.FeeTokens {
+			feeToken := &chainInfo.Fees.FeeTokens[i]
+			feeTokens[gasKey{chainName: chainInfo.ChainName, denom: feeToken.Denom}] = feeToken
+		}
+	}
+	for key := range gasManager.boundsOverrides {
+		if _, hasFeeToken := feeTokens[key]; !hasFeeToken {
+			feeTokens[key] = nil
+		}
+	}
+	for key, feeToken := range feeTokens {
+		if _, hasBounds := gasManager.priceBounds[key]; hasBounds {
+			continue
+		}
+
+		bounds, err := NewGasPriceBounds(feeToken, gasManager.boundsOverrides[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid gas price bounds for %s on %s: %w", key.denom, key.chainName, err)
+		}
+		gasManager.priceBounds[key] = bounds
+	}
+
+	if gasManager.factorSuccessThreshold <= 0 || gasManager.factorSuccessThreshold > gasManager.maxFactorSuccessThreshold {
+		return nil, fmt.Errorf("invalid gas factor success thresholds: %d, max %d. Must conform to: 0 < threshold <= max_threshold", gasManager.factorSuccessThreshold, gasManager.maxFactorSuccessThreshold)
+	}
+
 	return gasManager, nil
 }
 
-// Initialize a price. If already initialized, this is a no-op.
-func (g *geometricGasManager) InitializePrice(chainName string, gasPrice float64) error {
+// Initialize a price. If already initialized, this is a no-op. Prices outside of the chain's bounds are rejected.
+//
+// The price is remembered, and used if the chain's price is later removed from the provider, for instance by a gas admin reset.
+func (g *geometricGasManager) InitializePrice(chainName, denom string, gasPrice float64) error {
+	bounds, hasBounds := g.priceBounds[gasKey{chainName: chainName, denom: denom}]
+	if hasBounds && !bounds.Contains(gasPrice) {
+		return fmt.Errorf("%w: %f%s for %s, min %f, max %f", ErrGasPriceOutOfBounds, gasPrice, denom, chainName, bounds.MinGasPrice, bounds.MaxGasPrice)
+	}
+
+	g.initialPricesLock.Lock()
+	g.initialPrices[gasKey{chainName: chainName, denom: denom}] = gasPrice
+	g.initialPricesLock.Unlock()
+
 	// Check if the price is initialized and warn if so
-	hasPrice, err := g.gasPriceProvider.HasGasPrice(chainName)
+	hasPrice, err := g.gasPriceProvider.HasGasPrice(chainName, denom)
 	if err != nil {
 		return err
 	}
 
 	if hasPrice {
-		g.logger.Warn("requested initialization of previously initialized price. this is a no-op.", "chain_name", chainName)
+		g.logger.Warn("requested initialization of previously initialized price. this is a no-op.", "chain_name", chainName, "denom", denom)
 		return nil
 	}
 
-	return g.gasPriceProvider.SetGasPrice(chainName, gasPrice)
+	return setGasPriceWithReason(g.gasPriceProvider, chainName, denom, gasPrice, "initialized")
 }
 
 // Get a gas price
-func (g *geometricGasManager) GetGasPrice(chainName string) (float64, error) {
+func (g *geometricGasManager) GetGasPrice(chainName, denom string) (float64, error) {
+	// Fixed prices apply before any feedback has moved the stored price to them
+	fixedStrategy, isFixed := g.priceStrategy(chainName).(*fixedGasPriceStrategy)
+	if isFixed && !g.pins.IsGasPricePinned(chainName, denom) {
+		return g.clampPrice(chainName, denom, fixedStrategy.price), nil
+	}
+
 	// Attempt to get a gas price, and return if successful.
-	gasPrice, err := g.gasPriceProvider.GetGasPrice(chainName)
+	gasPrice, err := g.gasPriceProvider.GetGasPrice(chainName, denom)
 	if err == ErrNoGasPrice {
-		g.logger.Warn("no gas price found for chain, setting gas to be zero", "chain_name", chainName)
-		return 0, nil
+		// Start from the initialized price if there was one, and otherwise from the lowest price the chain accepts, if it is known
+		g.initialPricesLock.Lock()
+		initialGasPrice, hasInitialGasPrice := g.initialPrices[gasKey{chainName: chainName, denom: denom}]
+		g.initialPricesLock.Unlock()
+		if hasInitialGasPrice {
+			g.logger.Warn("no gas price found for chain, using the initialized gas price", "chain_name", chainName, "denom", denom, "initial_gas_price", initialGasPrice)
+			return initialGasPrice, nil
+		}
+
+		minGasPrice := g.clampPrice(chainName, denom, 0)
+		g.logger.Warn("no gas price found for chain, using the min gas price", "chain_name", chainName, "denom", denom, "min_gas_price", minGasPrice)
+		return minGasPrice, nil
 	} else if err != nil {
 		return 0, err
 	}
 	return gasPrice, err
 }
 
-func (gm *geometricGasManager) GetGasFactor(chainName string) (float64, error) {
-	logger := gm.logger.With("chain_name", chainName, "default_gas_factor", defaultGasFactor)
+func (gm *geometricGasManager) GetGasFactor(chainName, denom string) (float64, error) {
+	logger := gm.logger.With("chain_name", chainName, "denom", denom, "default_gas_factor", defaultGasFactor)
 
 	// Attempt to get a gas factor, and return if successful.
-	gasFactor, err := gm.gasPriceProvider.GetGasFactor(chainName)
+	gasFactor, err := gm.gasPriceProvider.GetGasFactor(chainName, denom)
 	if err == ErrNoGasFactor {
 		logger.Warn("no gas factor found for chain, initializing as default")
-		err := gm.gasPriceProvider.SetGasFactor(chainName, defaultGasFactor)
+		err := setGasFactorWithReason(gm.gasPriceProvider, chainName, denom, defaultGasFactor, "initialized to default")
 		if err != nil {
 			logger.Error("unable to set set default gas factor for chain. recovering by returning default gas factor", "error", err.Error())
 		}
@@ -125,6 +295,13 @@ func (gm *geometricGasManager) GetGasFactor(chainName string) (float64, error) {
 	return gasFactor, err
 }
 
+// Copies share state, so feedback given to a copy is kept.
+func (g *geometricGasManager) withAdjustments(adjustments *gasAdjustments) (GasManager, bool) {
+	reporting := *g
+	reporting.adjustments = adjustments
+	return &reporting, true
+}
+
 // Feedback methods
 
 // Provides feedback to the gas manager.
@@ -133,7 +310,7 @@ func (gm *geometricGasManager) GetGasFactor(chainName string) (float64, error) {
 // - Polling for a transaction after a call and finding it included or not, or a broadcast result you know is a gas error.
 // NOTE: You probably don't want to call after both, as that provides duplicate feedback.
 
-func (g *geometricGasManager) ManageFailingBroadcastResult(chainName string, broadcastResult *txtypes.BroadcastTxResponse) error {
+func (g *geometricGasManager) ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error {
 	if broadcastResult == nil {
 		return fmt.Errorf("received nil broadcast tx result")
 	}
@@ -153,14 +330,14 @@ func (g *geometricGasManager) ManageFailingBroadcastResult(chainName string, bro
 	}
 
 	if isSuccess {
-		g.logger.Warn("tx broadcast result was successful, but asked gas manager to track a failure", "chain_name", chainName)
+		g.logger.Warn("tx broadcast result was successful, but asked gas manager to track a failure", "chain_name", chainName, "denom", denom)
 		return nil
 	}
 
-	return g.trackFailingCodeAndCodespace(code, codespace, chainName, logs, uint(broadcastResult.TxResponse.GasWanted))
+	return g.trackFailingCodeAndCodespace(code, codespace, chainName, denom, logs, uint(broadcastResult.TxResponse.GasWanted))
 }
 
-func (g *geometricGasManager) ManageIncludedTransactionStatus(chainName string, txStatus *txtypes.GetTxResponse) error {
+func (g *geometricGasManager) ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error {
 	// Extract the code and logs from broadcast tx response
 	codespace := txStatus.TxResponse.Codespace
 	code := txStatus.TxResponse.Code
@@ -168,12 +345,12 @@ func (g *geometricGasManager) ManageIncludedTransactionStatus(chainName string,
 
 	// 1. If code was success, then ditch since this method only manages failures.
 	if IsSuccessTxStatus(txStatus) {
-		err := g.trackGasPriceSuccess(chainName)
+		err := g.trackGasPriceSuccess(chainName, denom)
 		if err != nil {
 			return err
 		}
 
-		err = g.trackGasFactorSuccess(chainName)
+		err = g.trackGasFactorSuccess(chainName, denom)
 		if err != nil {
 			return err
 		}
@@ -182,142 +359,196 @@ func (g *geometricGasManager) ManageIncludedTransactionStatus(chainName string,
 	}
 
 	// Otherwise, use core tracking logic
-	return g.trackFailingCodeAndCodespace(code, codespace, chainName, logs, uint(txStatus.TxResponse.GasWanted))
+	return g.trackFailingCodeAndCodespace(code, codespace, chainName, denom, logs, uint(txStatus.TxResponse.GasWanted))
 }
 
 // This only tracks gas price
-func (g *geometricGasManager) ManageInclusionFailure(chainName string) error {
-	return g.trackGasPriceFailure(chainName)
+func (g *geometricGasManager) ManageInclusionFailure(chainName, denom string) error {
+	return g.trackGasPriceFailure(chainName, denom, "tx was not included")
 }
 
 // Helpers - state tracking
 
-func (g *geometricGasManager) trackFailingCodeAndCodespace(code uint32, codespace, chainName, logs string, gasWanted uint) error {
-	logger := g.logger.With("chain_name", chainName, "code", code, "codespace", codespace, "logs", logs)
-
-	// 2. If the code was not a gas error, then it is non-deterministic, so do nothing.
-	if !IsGasRelatedError(codespace, code) {
-		logger.Info("broadcast result was unrelated to gas. not adjusting gas prices or gas factor")
-		return nil
-	}
+func (g *geometricGasManager) trackFailingCodeAndCodespace(code uint32, codespace, chainName, denom, logs string, gasWanted uint) error {
+	logger := g.logger.With("chain_name", chainName, "denom", denom, "code", code, "codespace", codespace, "logs", logs)
+	cause := fmt.Sprintf("code %d in codespace %s", code, codespace)
 
-	// 3. Manage failures do to gas price
-	if IsGasPriceError(codespace, code) {
-		// 3a. Grab the old price, which is useful for logging.
-		oldGasPrice, err := g.GetGasPrice(chainName)
+	failure := abci.ForChain(chainName).FromCode(codespace, code, logs)
+	switch {
+	case errors.Is(failure, abci.ErrInsufficientFee):
+		// 2. Manage failures do to gas price
+		// 2a. Grab the old price, which is useful for logging.
+		oldGasPrice, err := g.GetGasPrice(chainName, denom)
 		if err != nil {
 			return err
 		}
 
-		// 3b. Otherwise, it is a gas error so track a failure (which might auto adjust)
-		err = g.trackGasPriceFailure(chainName)
+		// 2b. Otherwise, it is a gas error so track a failure (which might auto adjust)
+		err = g.trackGasPriceFailure(chainName, denom, cause)
 		if err != nil {
 			return err
 		}
 
-		// 3c. If the network gave us a price, we can just use that one though.
-		chainSuggestedFee, err := extractMinGlobalFee(logs)
-		if err == nil {
-			// Determine the gas price by dividing the fee by the gas units requested
-			if gasWanted == 0 {
-				return fmt.Errorf("gas wanted cannot be zero")
-			}
-			newGasPrice := chainSuggestedFee / float64(gasWanted)
+		// 2c. If the network told us the fee it requires, we can just jump straight to that price. Unless an operator has pinned the price.
+		if g.pins.IsGasPricePinned(chainName, denom) {
+			logger.Info("gas price is pinned, not adjusting to the chain's required fee")
+			return nil
+		}
+		feeRequirement, format, err := g.feeErrorParsers.Parse(logs)
+		if err != nil {
+			logger.Debug("unable to parse required fee from logs", "error", err.Error())
+			return nil
+		}
 
-			// Set and log
-			err = g.gasPriceProvider.SetGasPrice(chainName, newGasPrice)
-			if err != nil {
-				return err
-			}
-			logger.Info("calculated exact price from chain suggestion", "old_gas_price", oldGasPrice, "new_gas_price", newGasPrice)
+		requiredFee, err := feeRequirement.RequiredFee(denom)
+		if err != nil {
+			logger.Warn("parsed fee error but could not determine required fee", "format", format, "error", err.Error())
+			return nil
 		}
+
+		// Determine the gas price by dividing the fee by the gas units requested
+		if gasWanted == 0 {
+			return fmt.Errorf("gas wanted cannot be zero")
+		}
+		requiredAmount, err := requiredFee.Amount.Float64()
+		if err != nil {
+			return err
+		}
+		newGasPrice := g.clampPrice(chainName, denom, requiredAmount/float64(gasWanted))
+
+		// Set and log
+		reason := fmt.Sprintf("chain required fee %s (%s format) after %s", requiredFee.String(), format, cause)
+		err = setGasPriceWithReason(g.gasPriceProvider, chainName, denom, newGasPrice, reason)
+		if err != nil {
+			return err
+		}
+		g.adjustments.record(chainName, denom, GasPriceChange, oldGasPrice, newGasPrice, reason)
+		logger.Info("calculated exact price from chain suggestion", "format", format, "required_fee", requiredFee.String(), "old_gas_price", oldGasPrice, "new_gas_price", newGasPrice)
+		return nil
+	case errors.Is(failure, abci.ErrOutOfGas):
+		// 3. Manage failures due to gas amount
+		return g.trackGasFactorFailure(chainName, denom, cause)
+	default:
+		// 4. If the code was not a gas error, then it is non-deterministic, so do nothing.
+		logger.Info("broadcast result was unrelated to gas. not adjusting gas prices or gas factor")
 		return nil
-	} else if isGasAmountError(codespace, code) {
-		return g.trackGasFactorFailure(chainName)
-	} else {
-		// This should never happen...
-		panic(fmt.Errorf("unexpected condition in gas manager adjustments with code %d and codespace %s", code, codespace))
 	}
 }
 
-func (g *geometricGasManager) trackGasFactorFailure(chainName string) error {
-	// Lock for map updates
+func (g *geometricGasManager) trackGasFactorFailure(chainName, denom, cause string) error {
+	// Lock for streak updates
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	// Accounting
-	g.consecutiveGasFactorSuccesses[chainName] = 0
-
-	failures := g.consecutiveGasFactorFailures[chainName] + 1
-	g.consecutiveGasFactorFailures[chainName] = failures
+	return g.updateGasStreak(chainName, denom, func(streak *GasStreak) error {
+		// Accounting
+		streak.GasFactorSuccesses = 0
+		streak.GasFactorFailures++
 
-	return g.adjustFactor(chainName, 0, failures)
+		return g.adjustFactor(chainName, denom, streak, cause)
+	})
 }
 
-func (g *geometricGasManager) trackGasFactorSuccess(chainName string) error {
-	// Lock for map updates
+func (g *geometricGasManager) trackGasFactorSuccess(chainName, denom string) error {
+	// Lock for streak updates
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	// Accounting
-	g.consecutiveGasFactorFailures[chainName] = 0
+	return g.updateGasStreak(chainName, denom, func(streak *GasStreak) error {
+		// Accounting
+		streak.GasFactorFailures = 0
+		streak.GasFactorSuccesses++
 
-	successes := g.consecutiveGasFactorSuccesses[chainName] + 1
-	g.consecutiveGasFactorSuccesses[chainName] = successes
-
-	return g.adjustFactor(chainName, successes, 0)
+		return g.adjustFactor(chainName, denom, streak, "")
+	})
 }
 
-func (g *geometricGasManager) trackGasPriceFailure(chainName string) error {
-	// Lock for map updates
+func (g *geometricGasManager) trackGasPriceFailure(chainName, denom, cause string) error {
+	// Lock for streak updates
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	// Accounting
-	g.consecutiveGasPriceSuccesses[chainName] = 0
-
-	failures := g.consecutiveGasPriceFailures[chainName] + 1
-	g.consecutiveGasPriceFailures[chainName] = failures
+	return g.updateGasStreak(chainName, denom, func(streak *GasStreak) error {
+		// Accounting
+		streak.GasPriceSuccesses = 0
+		streak.GasPriceFailures++
 
-	// Adjustments
-	return g.adjustPrice(chainName, 0, failures)
+		// Adjustments
+		return g.adjustPrice(chainName, denom, 0, streak.GasPriceFailures, cause)
+	})
 }
 
-func (g *geometricGasManager) trackGasPriceSuccess(chainName string) error {
-	// Lock for map updates
+func (g *geometricGasManager) trackGasPriceSuccess(chainName, denom string) error {
+	// Lock for streak updates
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
-	// Accounting
-	g.consecutiveGasPriceFailures[chainName] = 0
+	return g.updateGasStreak(chainName, denom, func(streak *GasStreak) error {
+		// Accounting
+		streak.GasPriceFailures = 0
+		streak.GasPriceSuccesses++
 
-	successes := g.consecutiveGasPriceSuccesses[chainName] + 1
-	g.consecutiveGasPriceSuccesses[chainName] = successes
+		// Adjustments
+		return g.adjustPrice(chainName, denom, streak.GasPriceSuccesses, 0, "")
+	})
+}
+
+// Load the streak for a chain and denom, apply an update, and save it. The streak is saved even if the update fails, since the outcome
+// was still observed.
+func (g *geometricGasManager) updateGasStreak(chainName, denom string, update func(streak *GasStreak) error) error {
+	streak, err := g.gasPriceProvider.GetGasStreak(chainName, denom)
+	if err == ErrNoGasStreak {
+		streak = &GasStreak{}
+	} else if err != nil {
+		return err
+	}
 
-	g.consecutiveGasPriceFailures[chainName]++
+	updateErr := update(streak)
 
-	// Adjustments
-	return g.adjustPrice(chainName, successes, 0)
+	err = g.gasPriceProvider.SetGasStreak(chainName, denom, streak)
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
 }
 
 // Helpers -  adjustments
 
-// TODO: config params
-const (
-	baseFactorSuccessThreshold = 10
-	factorStepSize             = 0.01
-)
-
-var (
-	maxFactorThreshold            = 100
-	currentFactorSuccessThreshold = baseFactorSuccessThreshold
-	isTryingToStepDownFactor      = false
-)
+// Get the gas factor state for a chain, initializing to defaults if none exists.
+func (g *geometricGasManager) getGasFactorState(chainName, denom string) (*GasFactorState, error) {
+	gasFactorState, err := g.gasPriceProvider.GetGasFactorState(chainName, denom)
+	if err == ErrNoGasFactorState {
+		return &GasFactorState{
+			IsTryingToStepDown: false,
+			SuccessThreshold:   g.factorSuccessThreshold,
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return gasFactorState, nil
+}
 
+// Adjust a factor. The cause of the most recent failure, if any, is recorded with the change.
+//
 // TODO: Theoretically this could just be injected to allow generalization. That feels over-optimizey for now.
-func (g *geometricGasManager) adjustFactor(chainName string, successes, failures int) error {
+func (g *geometricGasManager) adjustFactor(chainName, denom string, streak *GasStreak, cause string) error {
+	successes := streak.GasFactorSuccesses
+	failures := streak.GasFactorFailures
+
+	// Streaks are still tracked, but pinned factors are left alone
+	if g.pins.IsGasFactorPinned(chainName, denom) {
+		g.logger.Debug("gas factor is pinned, not adjusting", "chain_name", chainName, "denom", denom)
+		return nil
+	}
+
 	// Get starting factor
-	oldFactor, err := g.GetGasFactor(chainName)
+	oldFactor, err := g.GetGasFactor(chainName, denom)
+	if err != nil {
+		return err
+	}
+
+	// Get the state of adjustments for the chain
+	state, err := g.getGasFactorState(chainName, denom)
 	if err != nil {
 		return err
 	}
@@ -325,108 +556,131 @@ func (g *geometricGasManager) adjustFactor(chainName string, successes, failures
 	var newFactor float64
 
 	// See if we were testing a lower gas factor
-	if isTryingToStepDownFactor {
+	if state.IsTryingToStepDown {
 		// We're through our stepping.
-		isTryingToStepDownFactor = false
+		state.IsTryingToStepDown = false
 
 		// If we were trying to step down and we failed, increase threshold (bounding) and step back
 		if failures > 0 {
-			currentFactorSuccessThreshold += baseFactorSuccessThreshold
-			if currentFactorSuccessThreshold > maxFactorThreshold {
-				currentFactorSuccessThreshold = maxFactorThreshold
+			state.SuccessThreshold += g.factorSuccessThreshold
+			if state.SuccessThreshold > g.maxFactorSuccessThreshold {
+				state.SuccessThreshold = g.maxFactorSuccessThreshold
 			}
 
-			newFactor = oldFactor + factorStepSize
+			newFactor = oldFactor + g.factorStepSize
 		} else {
 			// New gas factor worked. Reset factor to baseline and reset successes to zero
-			currentFactorSuccessThreshold = baseFactorSuccessThreshold
-			g.consecutiveGasFactorSuccesses[chainName] = 0
-			return nil
+			state.SuccessThreshold = g.factorSuccessThreshold
+			streak.GasFactorSuccesses = 0
+			return g.gasPriceProvider.SetGasFactorState(chainName, denom, state)
 		}
 	} else {
 		// Do nothing if we don't have a failure or a consecutive success
-		if failures == 0 && successes < currentFactorSuccessThreshold {
+		if failures == 0 && successes < state.SuccessThreshold {
 			return nil
 		}
 
 		if failures > 0 {
-			newFactor = oldFactor + factorStepSize
+			newFactor = oldFactor + g.factorStepSize
 		} else {
-			newFactor = oldFactor - factorStepSize
+			newFactor = oldFactor - g.factorStepSize
 			if newFactor < 0 {
 				newFactor = 0
 			}
 
 			// Set that we're trying to step down.
-			isTryingToStepDownFactor = true
+			state.IsTryingToStepDown = true
 		}
 	}
 
-	err = g.gasPriceProvider.SetGasFactor(chainName, newFactor)
+	err = g.gasPriceProvider.SetGasFactorState(chainName, denom, state)
 	if err != nil {
 		return err
 	}
 
-	g.logger.Info("adjusted gas factor in response to feedback", "chain_name", chainName, "old_gas_factor", oldFactor, "consecutive_successes", successes, "consecutive_failures", failures, "new_gas_factor", newFactor)
+	reason := streakReason(successes, failures, cause)
+	err = setGasFactorWithReason(g.gasPriceProvider, chainName, denom, newFactor, reason)
+	if err != nil {
+		return err
+	}
+	g.adjustments.record(chainName, denom, GasFactorChange, oldFactor, newFactor, reason)
+
+	g.logger.Info("adjusted gas factor in response to feedback", "chain_name", chainName, "denom", denom, "old_gas_factor", oldFactor, "consecutive_successes", successes, "consecutive_failures", failures, "new_gas_factor", newFactor)
 	return nil
 }
 
-// TODO: Theoretically this could just be injected to allow generalization. That feels over-optimizey for now.
-func (g *geometricGasManager) adjustPrice(chainName string, successes, failures int) error {
-	logger := g.logger.With("max_step_size", g.maxStepSize)
+// Get the gas price strategy for a chain
+func (g *geometricGasManager) priceStrategy(chainName string) GasPriceStrategy {
+	strategy, found := g.priceStrategies[chainName]
+	if !found {
+		return g.defaultPriceStrategy
+	}
+	return strategy
+}
+
+// Clamp a price to the chain's bounds, if it has any.
+func (g *geometricGasManager) clampPrice(chainName, denom string, gasPrice float64) float64 {
+	bounds, hasBounds := g.priceBounds[gasKey{chainName: chainName, denom: denom}]
+	if !hasBounds {
+		return gasPrice
+	}
 
-	// Do nothing if we don't have a failure or a consecutive success
-	successThreshold := 5
-	if failures == 0 && successes < 5 {
+	clampedPrice := bounds.Clamp(gasPrice)
+	if clampedPrice != gasPrice {
+		g.logger.Warn("clamped gas price to bounds", "chain_name", chainName, "denom", denom, "desired_gas_price", gasPrice, "clamped_gas_price", clampedPrice, "min_gas_price", bounds.MinGasPrice, "max_gas_price", bounds.MaxGasPrice)
+	}
+	return clampedPrice
+}
+
+// Adjust a price using the chain's strategy. The cause of the most recent failure, if any, is recorded with the change.
+func (g *geometricGasManager) adjustPrice(chainName, denom string, successes, failures int, cause string) error {
+	// Streaks are still tracked, but pinned prices are left alone
+	if g.pins.IsGasPricePinned(chainName, denom) {
+		g.logger.Debug("gas price is pinned, not adjusting", "chain_name", chainName, "denom", denom)
 		return nil
 	}
 
 	// Get starting price
-	oldPrice, err := g.GetGasPrice(chainName)
+	oldPrice, err := g.GetGasPrice(chainName, denom)
 	if err != nil {
 		return err
 	}
 
-	var newPrice float64
-	if failures > 0 {
-		// Failures increasing. Scale price up according to consecutive failures
-		scale := math.Pow((1 + g.scaleFactor), float64(failures))
-		scaledStepSize := g.stepSize * scale
-		logger = logger.With("desired_step_size", scaledStepSize)
-
-		if scaledStepSize > g.maxStepSize {
-			logger.Warn("bounding step size")
-			scaledStepSize = g.maxStepSize
-		}
-
-		newPrice = oldPrice + scaledStepSize
-	} else {
-		// // Successes increasing, test a decrease of one step
-		// newPrice = oldPrice - g.stepSize
-		// if newPrice < 0 {
-		// 	newPrice = 0
-		// }
-
-		// Failures increasing. Scale price up according to consecutive failures
-		scale := math.Pow((1 + g.scaleFactor), float64(successes-successThreshold))
-		scaledStepSize := g.stepSize * scale
-
-		if scaledStepSize > g.maxStepSize {
-			logger.Warn("bounding step size")
-			scaledStepSize = g.maxStepSize
-		}
-
-		newPrice = oldPrice - scaledStepSize
-		if newPrice < 0 {
-			newPrice = 0
-		}
+	// Ask the strategy for a new price, and do nothing if it does not want to adjust.
+	strategy := g.priceStrategy(chainName)
+	feedback := GasPriceFeedback{
+		Denom:     denom,
+		Price:     oldPrice,
+		Successes: successes,
+		Failures:  failures,
+	}
+	newPrice, shouldAdjust := strategy.AdjustPrice(chainName, feedback)
+	if !shouldAdjust {
+		return nil
+	}
+	newPrice = g.clampPrice(chainName, denom, newPrice)
+	if newPrice == oldPrice {
+		return nil
 	}
 
-	err = g.gasPriceProvider.SetGasPrice(chainName, newPrice)
+	reason := fmt.Sprintf("%s strategy: %s", strategy.Name(), streakReason(successes, failures, cause))
+	err = setGasPriceWithReason(g.gasPriceProvider, chainName, denom, newPrice, reason)
 	if err != nil {
 		return err
 	}
+	g.adjustments.record(chainName, denom, GasPriceChange, oldPrice, newPrice, reason)
 
-	g.logger.Info("adjusted gas price in response to feedback", "chain_name", chainName, "old_gas_price", oldPrice, "consecutive_successes", successes, "consecutive_failures", failures, "new_gas_price", newPrice)
+	g.logger.Info("adjusted gas price in response to feedback", "chain_name", chainName, "denom", denom, "strategy", strategy.Name(), "old_gas_price", oldPrice, "consecutive_successes", successes, "consecutive_failures", failures, "new_gas_price", newPrice)
 	return nil
 }
+
+// Describe a streak of outcomes, for recording in gas history.
+func streakReason(successes, failures int, cause string) string {
+	if failures == 0 {
+		return fmt.Sprintf("%d consecutive successes", successes)
+	}
+	if cause == "" {
+		return fmt.Sprintf("%d consecutive failures", failures)
+	}
+	return fmt.Sprintf("%d consecutive failures, last was %s", failures, cause)
+}
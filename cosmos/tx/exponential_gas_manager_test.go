@@ -0,0 +1,208 @@
+package tx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	registry "github.com/tessellated-io/pickaxe/cosmos/chain-registry"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+func successfulTxStatus() *txtypes.GetTxResponse {
+	return &txtypes.GetTxResponse{
+		TxResponse: &sdk.TxResponse{Code: 0, GasWanted: 100_000},
+	}
+}
+
+func TestGeometricGasManager_GasFactorStateIsPerChain(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	gasManager, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default(), tx.WithGasFactorSuccessThreshold(2))
+	require.NoError(t, err)
+
+	// Two successes on chain A begins a step down trial.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain-a", "ufoo", successfulTxStatus()))
+	}
+
+	stateA, err := provider.GetGasFactorState("chain-a", "ufoo")
+	require.NoError(t, err)
+	require.True(t, stateA.IsTryingToStepDown)
+
+	// Chain B should not be in a trial.
+	require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain-b", "ufoo", successfulTxStatus()))
+	_, err = provider.GetGasFactorState("chain-b", "ufoo")
+	require.ErrorIs(t, err, tx.ErrNoGasFactorState)
+
+	gasFactorB, err := gasManager.GetGasFactor("chain-b", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 1.1, gasFactorB)
+}
+
+func TestGeometricGasManager_InvalidFactorThresholds(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	_, err = tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default(), tx.WithGasFactorSuccessThreshold(50), tx.WithMaxGasFactorSuccessThreshold(10))
+	require.Error(t, err)
+}
+
+func TestGeometricGasManager_GasPriceBounds(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	maxOverride := 0.05
+	bounds, err := tx.NewGasPriceBounds(&registry.FeeToken{LowGasPrice: 0.01, HighGasPrice: 0.1}, &tx.GasPriceBoundsConfig{MaxGasPrice: &maxOverride})
+	require.NoError(t, err)
+	require.Equal(t, 0.01, bounds.MinGasPrice)
+	require.Equal(t, 0.05, bounds.MaxGasPrice)
+
+	gasManager, err := tx.NewGeometricGasManager(0.02, 0.1, 0.2, provider, log.Default(), tx.WithGasPriceBounds("chain", "ufoo", bounds))
+	require.NoError(t, err)
+
+	// Unset prices start at the min price
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.01, gasPrice)
+
+	// Initial prices outside the bounds are rejected
+	require.ErrorIs(t, gasManager.InitializePrice("chain", "ufoo", 0.001), tx.ErrGasPriceOutOfBounds)
+	require.ErrorIs(t, gasManager.InitializePrice("chain", "ufoo", 0.06), tx.ErrGasPriceOutOfBounds)
+	hasGasPrice, err := provider.HasGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.False(t, hasGasPrice)
+
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", 0.02))
+	gasPrice, err = gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.02, gasPrice)
+
+	// Failures are clamped to the max price
+	for i := 0; i < 5; i++ {
+		require.NoError(t, gasManager.ManageInclusionFailure("chain", "ufoo"))
+	}
+	gasPrice, err = gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.05, gasPrice)
+}
+
+func TestGeometricGasManager_GasPriceBoundsFromChainRegistry(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	chainInfo := &registry.ChainInfo{
+		ChainName: "chain",
+		Fees: registry.Fee{FeeTokens: []registry.FeeToken{
+			{Denom: "ufoo", FixedMinGasPrice: 0.02, HighGasPrice: 0.1},
+			{Denom: "ubar", LowGasPrice: 0.5, HighGasPrice: 1},
+		}},
+	}
+	explicitBounds := &tx.GasPriceBounds{MinGasPrice: 0.2, MaxGasPrice: 2}
+	gasManager, err := tx.NewGeometricGasManager(0.02, 0.1, 0.2, provider, log.Default(), tx.WithChainRegistry(chainInfo), tx.WithGasPriceBounds("chain", "ubar", explicitBounds))
+	require.NoError(t, err)
+
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.02, gasPrice)
+
+	// Explicit bounds take precedence
+	gasPrice, err = gasManager.GetGasPrice("chain", "ubar")
+	require.NoError(t, err)
+	require.Equal(t, 0.2, gasPrice)
+}
+
+func TestGeometricGasManager_GasPriceBoundsOverrides(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	chainInfo := &registry.ChainInfo{
+		ChainName: "chain",
+		Fees:      registry.Fee{FeeTokens: []registry.FeeToken{{Denom: "ufoo", LowGasPrice: 0.01, HighGasPrice: 0.1}}},
+	}
+	minOverride := 0.03
+	maxOverride := 1.0
+	gasManager, err := tx.NewGeometricGasManager(
+		0.02, 0.1, 0.2, provider, log.Default(), tx.WithChainRegistry(chainInfo),
+		tx.WithGasPriceBoundsOverrides("chain", "ufoo", &tx.GasPriceBoundsConfig{MinGasPrice: &minOverride}),
+		tx.WithGasPriceBoundsOverrides("chain", "ubar", &tx.GasPriceBoundsConfig{MaxGasPrice: &maxOverride}),
+	)
+	require.NoError(t, err)
+
+	// The overridden min applies, and the registry's max is kept
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.03, gasPrice)
+	require.ErrorIs(t, gasManager.InitializePrice("chain", "ufoo", 0.2), tx.ErrGasPriceOutOfBounds)
+
+	// Denoms missing from the registry are bounded by their overrides alone
+	require.ErrorIs(t, gasManager.InitializePrice("chain", "ubar", 2), tx.ErrGasPriceOutOfBounds)
+	require.NoError(t, gasManager.InitializePrice("chain", "ubar", 0.5))
+}
+
+func TestGeometricGasManager_FeedbackIsPerDenom(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+
+	gasManager, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default())
+	require.NoError(t, err)
+
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", 0.1))
+	require.NoError(t, gasManager.InitializePrice("chain", "ubar", 0.1))
+
+	require.NoError(t, gasManager.ManageInclusionFailure("chain", "ufoo"))
+
+	fooPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Greater(t, fooPrice, 0.1)
+
+	barPrice, err := gasManager.GetGasPrice("chain", "ubar")
+	require.NoError(t, err)
+	require.Equal(t, 0.1, barPrice)
+}
+
+func TestGeometricGasManager_StreaksSurviveRestarts(t *testing.T) {
+	t.Parallel()
+	dataDirectory := t.TempDir()
+
+	provider, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	gasManager, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default(), tx.WithGasFactorSuccessThreshold(3))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain", "ufoo", successfulTxStatus()))
+	}
+
+	streak, err := provider.GetGasStreak("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 2, streak.GasFactorSuccesses)
+
+	// After a restart, one more success completes the streak and begins a step down trial.
+	restartedProvider, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	restartedGasManager, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, restartedProvider, log.Default(), tx.WithGasFactorSuccessThreshold(3))
+	require.NoError(t, err)
+
+	require.NoError(t, restartedGasManager.ManageIncludedTransactionStatus("chain", "ufoo", successfulTxStatus()))
+
+	state, err := restartedProvider.GetGasFactorState("chain", "ufoo")
+	require.NoError(t, err)
+	require.True(t, state.IsTryingToStepDown)
+}
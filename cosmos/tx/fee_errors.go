@@ -0,0 +1,169 @@
+package tx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// FeeRequirement is the fee a chain reported that it required, parsed from a failing tx's raw log.
+type FeeRequirement struct {
+	// The fee that was provided in the tx. May be empty if the chain did not report it.
+	Provided sdk.DecCoins
+
+	// The fee that the chain requires. Some chains accept several denoms, in which case each will be present.
+	Required sdk.DecCoins
+}
+
+// RequiredFee returns the required fee in the given denom. If the chain does not accept the denom, an error is returned.
+func (fr *FeeRequirement) RequiredFee(denom string) (sdk.DecCoin, error) {
+	requiredAmount := fr.Required.AmountOf(denom)
+	if !requiredAmount.IsPositive() {
+		return sdk.DecCoin{}, fmt.Errorf("no required fee in denom %s. provided: %s, required: %s", denom, fr.Provided, fr.Required)
+	}
+
+	return sdk.NewDecCoinFromDec(denom, requiredAmount), nil
+}
+
+// FeeErrorParser extracts fee requirements from a failing tx's raw log.
+type FeeErrorParser interface {
+	// A name for the format the parser understands, for logging.
+	Name() string
+
+	// Parse a raw log. If the raw log was not in a format the parser understands, returns (nil, false).
+	Parse(rawLog string) (*FeeRequirement, bool)
+}
+
+// regexFeeErrorParser parses raw logs with a regular expression. The first capture group is the provided fee, and the second is the
+// required fee.
+type regexFeeErrorParser struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+var _ FeeErrorParser = (*regexFeeErrorParser)(nil)
+
+// NewRegexFeeErrorParser creates a FeeErrorParser from a pattern. The pattern must have two capture groups, which capture the provided
+// and required fees as coin strings (ex. "100uatom,200uosmo").
+func NewRegexFeeErrorParser(name, pattern string) (FeeErrorParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if re.NumSubexp() != 2 {
+		return nil, fmt.Errorf("fee error pattern must have exactly two capture groups, found %d", re.NumSubexp())
+	}
+
+	return &regexFeeErrorParser{
+		name:    name,
+		pattern: re,
+	}, nil
+}
+
+func (p *regexFeeErrorParser) Name() string {
+	return p.name
+}
+
+func (p *regexFeeErrorParser) Parse(rawLog string) (*FeeRequirement, bool) {
+	matches := p.pattern.FindStringSubmatch(rawLog)
+	if len(matches) != 3 {
+		return nil, false
+	}
+
+	// Chains may not always report what was provided, so tolerate failures
+	provided, err := parseFeeCoins(matches[1])
+	if err != nil {
+		provided = sdk.NewDecCoins()
+	}
+
+	required, err := parseFeeCoins(matches[2])
+	if err != nil || required.IsZero() {
+		return nil, false
+	}
+
+	return &FeeRequirement{
+		Provided: provided,
+		Required: required,
+	}, true
+}
+
+// Parse a coin string, trimming any punctuation the error format may have left behind.
+func parseFeeCoins(coins string) (sdk.DecCoins, error) {
+	coins = strings.Trim(coins, ":,.;()")
+	return sdk.ParseDecCoins(coins)
+}
+
+// Default parsers for known chain formats.
+var (
+	// Evmos, ex: "provided fee < minimum global fee (1000aevmos < 2000000aevmos). Please increase the gas price."
+	evmosFeeErrorParser = mustNewRegexFeeErrorParser("evmos", `\((\S*) < (\S+)\)\. Please increase`)
+
+	// Osmosis txfees, ex: "insufficient fees; got: 1uosmo which converts to 1uosmo. required: 2500uosmo"
+	osmosisFeeErrorParser = mustNewRegexFeeErrorParser("osmosis", `insufficient fees; got: (\S*) which converts to \S*\. required: (\S+)`)
+
+	// Gaia globalfee, ex: "fee is not a subset of required fees; got 1uosmo, required: 500uatom,100uosmo"
+	gaiaFeeErrorParser = mustNewRegexFeeErrorParser("gaia", `fee is not a subset of required fees; got (\S*), required: (\S+)`)
+
+	// Standard SDK mempool fee check, ex: "insufficient fees; got: 100uatom required: 2500uatom: insufficient fee"
+	sdkFeeErrorParser = mustNewRegexFeeErrorParser("sdk", `insufficient fees; got: ?(\S*) required: ?(\S+)`)
+)
+
+func mustNewRegexFeeErrorParser(name, pattern string) FeeErrorParser {
+	parser, err := NewRegexFeeErrorParser(name, pattern)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}
+
+// FeeErrorParserRegistry holds a set of parsers, which are tried in the order they were registered.
+type FeeErrorParserRegistry struct {
+	parsers []FeeErrorParser
+
+	lock *sync.RWMutex
+}
+
+// NewFeeErrorParserRegistry creates a registry with the given parsers.
+func NewFeeErrorParserRegistry(parsers ...FeeErrorParser) *FeeErrorParserRegistry {
+	return &FeeErrorParserRegistry{
+		parsers: parsers,
+		lock:    &sync.RWMutex{},
+	}
+}
+
+// DefaultFeeErrorParserRegistry creates a registry which understands Evmos, Osmosis, Gaia and standard SDK fee errors.
+func DefaultFeeErrorParserRegistry() *FeeErrorParserRegistry {
+	return NewFeeErrorParserRegistry(
+		evmosFeeErrorParser,
+		osmosisFeeErrorParser,
+		gaiaFeeErrorParser,
+		sdkFeeErrorParser,
+	)
+}
+
+// Register adds a parser, which will be tried after all previously registered parsers.
+func (r *FeeErrorParserRegistry) Register(parser FeeErrorParser) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.parsers = append(r.parsers, parser)
+}
+
+// Parse attempts to extract fee requirements from the raw log with each registered parser. The name of the matching parser is returned.
+func (r *FeeErrorParserRegistry) Parse(rawLog string) (*FeeRequirement, string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, parser := range r.parsers {
+		feeRequirement, ok := parser.Parse(rawLog)
+		if ok {
+			return feeRequirement, parser.Name(), nil
+		}
+	}
+
+	return nil, "", ErrUnrecognizedFeeError
+}
@@ -0,0 +1,91 @@
+package tx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+)
+
+func TestFeeErrorParsers_KnownFormats(t *testing.T) {
+	cases := []struct {
+		rawLog         string
+		expectedFormat string
+		denom          string
+		expectedFee    string
+	}{
+		{
+			rawLog:         "provided fee < minimum global fee (1000aevmos < 2000000aevmos). Please increase the gas price.: insufficient fee",
+			expectedFormat: "evmos",
+			denom:          "aevmos",
+			expectedFee:    "2000000.000000000000000000aevmos",
+		},
+		{
+			rawLog:         "insufficient fees; got: 1uosmo which converts to 1uosmo. required: 2500uosmo: insufficient fee",
+			expectedFormat: "osmosis",
+			denom:          "uosmo",
+			expectedFee:    "2500.000000000000000000uosmo",
+		},
+		{
+			rawLog:         "fee is not a subset of required fees; got 1uosmo, required: 500uatom,100uosmo: insufficient fee",
+			expectedFormat: "gaia",
+			denom:          "uosmo",
+			expectedFee:    "100.000000000000000000uosmo",
+		},
+		{
+			rawLog:         "insufficient fees; got: 100uatom required: 2500uatom: insufficient fee",
+			expectedFormat: "sdk",
+			denom:          "uatom",
+			expectedFee:    "2500.000000000000000000uatom",
+		},
+	}
+
+	registry := tx.DefaultFeeErrorParserRegistry()
+	for _, c := range cases {
+		feeRequirement, format, err := registry.Parse(c.rawLog)
+		require.NoError(t, err, c.rawLog)
+		require.Equal(t, c.expectedFormat, format)
+
+		requiredFee, err := feeRequirement.RequiredFee(c.denom)
+		require.NoError(t, err)
+		require.Equal(t, c.expectedFee, requiredFee.String())
+	}
+}
+
+func TestFeeErrorParsers_MultipleDenoms(t *testing.T) {
+	registry := tx.DefaultFeeErrorParserRegistry()
+
+	feeRequirement, _, err := registry.Parse("insufficient fees; got:  required: 2500uatom,300uosmo: insufficient fee")
+	require.NoError(t, err)
+	require.Len(t, feeRequirement.Required, 2)
+
+	requiredFee, err := feeRequirement.RequiredFee("uosmo")
+	require.NoError(t, err)
+	require.Equal(t, "300.000000000000000000uosmo", requiredFee.String())
+
+	_, err = feeRequirement.RequiredFee("ujuno")
+	require.Error(t, err)
+}
+
+func TestFeeErrorParsers_Unrecognized(t *testing.T) {
+	registry := tx.DefaultFeeErrorParserRegistry()
+
+	_, _, err := registry.Parse("out of gas in location: ReadFlat; gasWanted: 100, gasUsed: 200: out of gas")
+	require.ErrorIs(t, err, tx.ErrUnrecognizedFeeError)
+}
+
+func TestFeeErrorParsers_Register(t *testing.T) {
+	parser, err := tx.NewRegexFeeErrorParser("custom", `paid (\S+) but need (\S+)`)
+	require.NoError(t, err)
+
+	registry := tx.NewFeeErrorParserRegistry()
+	registry.Register(parser)
+
+	feeRequirement, format, err := registry.Parse("paid 10ujuno but need 20ujuno")
+	require.NoError(t, err)
+	require.Equal(t, "custom", format)
+
+	requiredFee, err := feeRequirement.RequiredFee("ujuno")
+	require.NoError(t, err)
+	require.Equal(t, "20.000000000000000000ujuno", requiredFee.String())
+}
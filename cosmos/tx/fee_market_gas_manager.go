@@ -0,0 +1,176 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/log"
+
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// How long to wait for the feemarket module to return a base fee
+const baseFeeQueryTimeout = 10 * time.Second
+
+// Gas manager for chains with an EIP-1559 style feemarket module, like Evmos.
+//
+// Rather than learning a gas price by failing, prices are calculated from the chain's current base fee:
+//
+//	Formula: price = (base_fee * multiplier) + priority_tip
+//
+// The base fee is queried at most once per interval. Gas factors and feedback are delegated to a wrapped gas manager. If the base fee cannot
+// be retrieved, or an operator has pinned the price, the wrapped gas manager's price is used.
+type feeMarketGasManager struct {
+	// Parameters
+	chainName       string
+	multiplier      float64
+	priorityTip     float64
+	baseFeeInterval time.Duration
+
+	// The last base fee retrieved, and when
+	baseFee          float64
+	baseFeeFetchedAt time.Time
+	baseFeeLock      *sync.Mutex
+
+	// Values which operators have pinned. May be nil.
+	pins *GasPins
+
+	// Services
+	logger    *log.Logger
+	rpcClient rpc.RpcClient
+	wrapped   GasManager
+}
+
+var (
+	_ adjustmentReportingGasManager = (*feeMarketGasManager)(nil)
+	_ gasUsageLearningGasManager    = (*feeMarketGasManager)(nil)
+)
+
+// NewFeeMarketGasManager creates a gas manager that prices txs for chainName from the feemarket base fee, queried at most once per
+// baseFeeInterval. Other chains, and prices pinned in pins, pass through to wrapped. pins may be nil.
+func NewFeeMarketGasManager(
+	chainName string,
+	multiplier float64,
+	priorityTip float64,
+	baseFeeInterval time.Duration,
+	rpcClient rpc.RpcClient,
+	wrapped GasManager,
+	pins *GasPins,
+	logger *log.Logger,
+) (GasManager, error) {
+	if multiplier < 1 {
+		return nil, fmt.Errorf("invalid multiplier: %f. Must conform to: multiplier >= 1", multiplier)
+	}
+	if priorityTip < 0 {
+		return nil, fmt.Errorf("invalid priority tip: %f. Must conform to: priority_tip >= 0", priorityTip)
+	}
+
+	gasManager := &feeMarketGasManager{
+		chainName:       chainName,
+		multiplier:      multiplier,
+		priorityTip:     priorityTip,
+		baseFeeInterval: baseFeeInterval,
+
+		baseFeeLock: &sync.Mutex{},
+
+		pins: pins,
+
+		logger:    logger.ApplyPrefix("⛽️"),
+		rpcClient: rpcClient,
+		wrapped:   wrapped,
+	}
+
+	return gasManager, nil
+}
+
+func (g *feeMarketGasManager) InitializePrice(chainName, denom string, gasPrice float64) error {
+	return g.wrapped.InitializePrice(chainName, denom, gasPrice)
+}
+
+// Get a gas price, calculated from the base fee if chainName is managed by the feemarket.
+func (g *feeMarketGasManager) GetGasPrice(chainName, denom string) (float64, error) {
+	if chainName != g.chainName || g.pins.IsGasPricePinned(chainName, denom) {
+		return g.wrapped.GetGasPrice(chainName, denom)
+	}
+	logger := g.logger.With("chain_name", chainName, "denom", denom, "multiplier", g.multiplier, "priority_tip", g.priorityTip)
+
+	baseFeeFloat, err := g.getBaseFee()
+	if err != nil {
+		logger.Warn("unable to query base fee, falling back to wrapped gas manager", "error", err.Error())
+		return g.wrapped.GetGasPrice(chainName, denom)
+	}
+
+	gasPrice := (baseFeeFloat * g.multiplier) + g.priorityTip
+	logger.Debug("calculated gas price from base fee", "base_fee", baseFeeFloat, "gas_price", gasPrice)
+
+	return gasPrice, nil
+}
+
+// Get the base fee, querying it if the last one is stale. The query is made without holding the lock, so a slow node does not block
+// readers of a fresh base fee.
+func (g *feeMarketGasManager) getBaseFee() (float64, error) {
+	g.baseFeeLock.Lock()
+	baseFee, fetchedAt := g.baseFee, g.baseFeeFetchedAt
+	g.baseFeeLock.Unlock()
+
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < g.baseFeeInterval {
+		return baseFee, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), baseFeeQueryTimeout)
+	defer cancel()
+
+	baseFeeDec, err := g.rpcClient.GetBaseFee(ctx)
+	if err != nil {
+		return 0, err
+	}
+	baseFee, err = baseFeeDec.Float64()
+	if err != nil {
+		return 0, err
+	}
+
+	g.baseFeeLock.Lock()
+	g.baseFee = baseFee
+	g.baseFeeFetchedAt = time.Now()
+	g.baseFeeLock.Unlock()
+
+	return baseFee, nil
+}
+
+func (g *feeMarketGasManager) GetGasFactor(chainName, denom string) (float64, error) {
+	return g.wrapped.GetGasFactor(chainName, denom)
+}
+
+// Feedback methods
+//
+// Feedback is passed through so that gas factors and fallback prices continue to be tracked.
+
+func (g *feeMarketGasManager) withAdjustments(adjustments *gasAdjustments) (GasManager, bool) {
+	wrapped, ok := withGasAdjustments(g.wrapped, adjustments)
+	if !ok {
+		return g, false
+	}
+
+	reporting := *g
+	reporting.wrapped = wrapped
+	return &reporting, true
+}
+
+func (g *feeMarketGasManager) ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error {
+	return g.wrapped.ManageFailingBroadcastResult(chainName, denom, broadcastResult)
+}
+
+func (g *feeMarketGasManager) ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error {
+	return g.wrapped.ManageIncludedTransactionStatus(chainName, denom, txStatus)
+}
+
+func (g *feeMarketGasManager) manageIncludedTransactionGasFactor(chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64) error {
+	return manageIncludedTransactionStatus(g.wrapped, chainName, denom, txStatus, gasFactor, true)
+}
+
+func (g *feeMarketGasManager) ManageInclusionFailure(chainName, denom string) error {
+	return g.wrapped.ManageInclusionFailure(chainName, denom)
+}
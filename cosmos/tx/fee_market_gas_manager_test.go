@@ -0,0 +1,72 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// baseFeeRpcClient serves a base fee, or an error. Other RPCs are not implemented.
+type baseFeeRpcClient struct {
+	rpc.RpcClient
+
+	baseFee sdk.Dec
+	err     error
+	queries int
+}
+
+func (c *baseFeeRpcClient) GetBaseFee(ctx context.Context) (sdk.Dec, error) {
+	c.queries++
+	return c.baseFee, c.err
+}
+
+func TestFeeMarketGasManager_PricesFromBaseFee(t *testing.T) {
+	rpcClient := &baseFeeRpcClient{baseFee: sdk.MustNewDecFromStr("2")}
+	gasManager, err := tx.NewFeeMarketGasManager("chain", 1.5, 0.1, time.Hour, rpcClient, newBacktestGasManager(t, 0.01), nil, log.Default())
+	require.NoError(t, err)
+
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.InDelta(t, 3.1, gasPrice, 1e-9)
+
+	// The base fee is cached for the interval
+	rpcClient.baseFee = sdk.MustNewDecFromStr("4")
+	gasPrice, err = gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.InDelta(t, 3.1, gasPrice, 1e-9)
+	require.Equal(t, 1, rpcClient.queries)
+}
+
+func TestFeeMarketGasManager_FallsBackWithoutBaseFee(t *testing.T) {
+	rpcClient := &baseFeeRpcClient{err: errors.New("no feemarket module")}
+	gasManager, err := tx.NewFeeMarketGasManager("chain", 1.5, 0, 0, rpcClient, newBacktestGasManager(t, 0.01), nil, log.Default())
+	require.NoError(t, err)
+
+	// Errors fall back to the wrapped manager's price, and are not cached
+	for i := 0; i < 2; i++ {
+		gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+		require.NoError(t, err)
+		require.Equal(t, 0.01, gasPrice)
+	}
+	require.Equal(t, 2, rpcClient.queries)
+
+	// Other chains are passed through without querying
+	_, _ = gasManager.GetGasPrice("other-chain", "ufoo")
+	require.Equal(t, 2, rpcClient.queries)
+}
+
+func TestFeeMarketGasManager_RejectsInvalidParameters(t *testing.T) {
+	_, err := tx.NewFeeMarketGasManager("chain", 0.5, 0, time.Hour, &baseFeeRpcClient{}, newBacktestGasManager(t, 0.01), nil, log.Default())
+	require.Error(t, err)
+
+	_, err = tx.NewFeeMarketGasManager("chain", 1, -1, time.Hour, &baseFeeRpcClient{}, newBacktestGasManager(t, 0.01), nil, log.Default())
+	require.Error(t, err)
+}
@@ -2,55 +2,169 @@ package tx
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strconv"
 	"sync"
+	"time"
 
+	"github.com/tessellated-io/pickaxe/config"
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
 	"github.com/tessellated-io/pickaxe/log"
 
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 )
 
 // GasManager interprets tx results and associated outcomes.
+//
+// Chains may accept several fee denoms, so prices and factors are managed per chain and denom.
 type GasManager interface {
-	InitializePrice(chainName string, gasPrice float64) error
+	InitializePrice(chainName, denom string, gasPrice float64) error
 
-	// Get a suggested gas price for the chainName
-	GetGasPrice(chainName string) (float64, error)
+	// Get a suggested gas price for the chainName, denominated in denom
+	GetGasPrice(chainName, denom string) (float64, error)
 
-	// Get a gas factor for the chain name
-	GetGasFactor(chainName string) (float64, error)
+	// Get a gas factor for the chain name, when paying fees in denom
+	GetGasFactor(chainName, denom string) (float64, error)
 
 	// Given a broadcast result for a chainName, update gas prices. Calling with a successful broadcast result is a no-op. Tally successes
 	// using ManageTransactionStatus.
-	ManageFailingBroadcastResult(chainName string, broadcastResult *txtypes.BroadcastTxResponse) error
+	ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error
 
 	// Manage a transaction status once it has settled on chain. Statuses could be positive or negative.
-	ManageIncludedTransactionStatus(chainName string, txStatus *txtypes.GetTxResponse) error
+	ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error
 
 	// Manage a failure in the case a tx was successfully broadcasted, but never landed on chain and we thus are unable to provide a tx status
-	ManageInclusionFailure(chainName string) error
+	ManageInclusionFailure(chainName, denom string) error
 }
 
-// GasPriceProvider is a simple KV store for gas.
+// adjustmentReportingGasManager is a GasManager which can report the changes its feedback methods make to gas prices and gas factors.
+type adjustmentReportingGasManager interface {
+	GasManager
+
+	// Get a copy of the manager which records changes into adjustments. Returns false if the manager cannot report changes, for instance
+	// because it wraps a manager which cannot.
+	withAdjustments(adjustments *gasAdjustments) (GasManager, bool)
+}
+
+// Changes made to gas prices and gas factors while managing a single result.
+type gasAdjustments struct {
+	changes []GasChange
+}
+
+// Record a change. Does nothing if adjustments is nil, so managers which are not reporting can call it freely.
+func (a *gasAdjustments) record(chainName, denom, kind string, oldValue, newValue float64, reason string) {
+	if a == nil {
+		return
+	}
+
+	a.changes = append(a.changes, GasChange{
+		Timestamp: time.Now().UTC(),
+		ChainName: chainName,
+		Denom:     denom,
+		Kind:      kind,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		Reason:    reason,
+	})
+}
+
+// Get a copy of gasManager which records changes into adjustments. Returns false if gasManager cannot report changes.
+func withGasAdjustments(gasManager GasManager, adjustments *gasAdjustments) (GasManager, bool) {
+	reportingGasManager, ok := gasManager.(adjustmentReportingGasManager)
+	if !ok {
+		return gasManager, false
+	}
+	return reportingGasManager.withAdjustments(adjustments)
+}
+
+// gasUsageLearningGasManager is a GasManager which learns from the gas included txs used, compared to their simulations. A tx's simulated gas
+// is its gas wanted divided by the gas factor it was built with, which may no longer be the factor for its chain by the time it lands.
+type gasUsageLearningGasManager interface {
+	GasManager
+
+	// Like ManageIncludedTransactionStatus, for a tx which was built with gasFactor.
+	manageIncludedTransactionGasFactor(chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64) error
+}
+
+// Manage the status of an included tx, passing the gas factor the tx was built with to gas managers which learn from gas usage. Falls back to
+// ManageIncludedTransactionStatus if the gas factor is not known, or gasManager does not learn from gas usage.
+func manageIncludedTransactionStatus(gasManager GasManager, chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64, knownGasFactor bool) error {
+	learningGasManager, ok := gasManager.(gasUsageLearningGasManager)
+	if !ok || !knownGasFactor {
+		return gasManager.ManageIncludedTransactionStatus(chainName, denom, txStatus)
+	}
+	return learningGasManager.manageIncludedTransactionGasFactor(chainName, denom, txStatus, gasFactor)
+}
+
+// Gas factors signed txs were built with, keyed by tx hash, until the txs land or can no longer land.
+type txGasFactors struct {
+	gasFactors map[string]float64
+	lock       *sync.Mutex
+}
+
+func newTxGasFactors() *txGasFactors {
+	return &txGasFactors{
+		gasFactors: make(map[string]float64),
+		lock:       &sync.Mutex{},
+	}
+}
+
+// Remember the gas factor txHash was built with.
+func (f *txGasFactors) record(txHash string, gasFactor float64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.gasFactors[txHash] = gasFactor
+}
+
+// Get and forget the gas factor txHash was built with. Returns false if it is not known.
+func (f *txGasFactors) take(txHash string) (float64, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	gasFactor, found := f.gasFactors[txHash]
+	delete(f.gasFactors, txHash)
+	return gasFactor, found
+}
+
+// GasPriceProvider is a simple KV store for gas, keyed by chain name and fee denom.
 type GasPriceProvider interface {
-	HasGasPrice(chainName string) (bool, error)
-	GetGasPrice(chainName string) (float64, error)
-	SetGasPrice(chainName string, gasPrice float64) error
+	HasGasPrice(chainName, denom string) (bool, error)
+	GetGasPrice(chainName, denom string) (float64, error)
+	SetGasPrice(chainName, denom string, gasPrice float64) error
+
+	HasGasFactor(chainName, denom string) (bool, error)
+	GetGasFactor(chainName, denom string) (float64, error)
+	SetGasFactor(chainName, denom string, gasFactor float64) error
+
+	GetGasFactorState(chainName, denom string) (*GasFactorState, error)
+	SetGasFactorState(chainName, denom string, gasFactorState *GasFactorState) error
+
+	GetGasStreak(chainName, denom string) (*GasStreak, error)
+	SetGasStreak(chainName, denom string, gasStreak *GasStreak) error
 
-	HasGasFactor(chainName string) (bool, error)
-	GetGasFactor(chainName string) (float64, error)
-	SetGasFactor(chainName string, gasFactor float64) error
+	// Remove gas prices, gas factors, gas factor states and streaks for all of a chain's denoms.
+	ResetGas(chainName string) error
 
 	getGasData() (*GasData, error)
+
+	// Set values as if they were set at updatedAt, recording the reason if the provider keeps history. A zero updatedAt leaves the value
+	// without a timestamp.
+	setGasPriceAt(chainName, denom string, gasPrice float64, updatedAt time.Time, reason string) error
+	setGasFactorAt(chainName, denom string, gasFactor float64, updatedAt time.Time, reason string) error
 }
 
 // InMemoryGasPriceProvider stores gas prices in memory.
 type InMemoryGasPriceProvider struct {
-	prices  map[string]float64
-	factors map[string]float64
+	prices       map[string]map[string]float64
+	factors      map[string]map[string]float64
+	factorStates map[string]map[string]GasFactorState
+	streaks      map[string]map[string]GasStreak
+
+	// When prices and factors were last set
+	pricesUpdatedAt  map[string]map[string]time.Time
+	factorsUpdatedAt map[string]map[string]time.Time
 
 	lock *sync.Mutex
 }
@@ -58,28 +172,36 @@ type InMemoryGasPriceProvider struct {
 var _ GasPriceProvider = (*InMemoryGasPriceProvider)(nil)
 
 func NewInMemoryGasPriceProvider() (GasPriceProvider, error) {
-	provider := &InMemoryGasPriceProvider{
-		prices:  make(map[string]float64),
-		factors: make(map[string]float64),
+	return newInMemoryGasPriceProvider(), nil
+}
+
+func newInMemoryGasPriceProvider() *InMemoryGasPriceProvider {
+	return &InMemoryGasPriceProvider{
+		prices:       make(map[string]map[string]float64),
+		factors:      make(map[string]map[string]float64),
+		factorStates: make(map[string]map[string]GasFactorState),
+		streaks:      make(map[string]map[string]GasStreak),
+
+		pricesUpdatedAt:  make(map[string]map[string]time.Time),
+		factorsUpdatedAt: make(map[string]map[string]time.Time),
 
 		lock: &sync.Mutex{},
 	}
-	return provider, nil
 }
 
-func (gp *InMemoryGasPriceProvider) HasGasPrice(chainName string) (bool, error) {
+func (gp *InMemoryGasPriceProvider) HasGasPrice(chainName, denom string) (bool, error) {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	_, found := gp.prices[chainName]
+	_, found := getByChainAndDenom(gp.prices, chainName, denom)
 	return found, nil
 }
 
-func (gp *InMemoryGasPriceProvider) GetGasPrice(chainName string) (float64, error) {
+func (gp *InMemoryGasPriceProvider) GetGasPrice(chainName, denom string) (float64, error) {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	gasPrice, found := gp.prices[chainName]
+	gasPrice, found := getByChainAndDenom(gp.prices, chainName, denom)
 	if !found {
 		return 0, ErrNoGasPrice
 	}
@@ -87,27 +209,32 @@ func (gp *InMemoryGasPriceProvider) GetGasPrice(chainName string) (float64, erro
 	return gasPrice, nil
 }
 
-func (gp *InMemoryGasPriceProvider) SetGasPrice(chainName string, gasPrice float64) error {
+func (gp *InMemoryGasPriceProvider) SetGasPrice(chainName, denom string, gasPrice float64) error {
+	return gp.setGasPriceAt(chainName, denom, gasPrice, time.Now().UTC(), "")
+}
+
+func (gp *InMemoryGasPriceProvider) setGasPriceAt(chainName, denom string, gasPrice float64, updatedAt time.Time, reason string) error {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	gp.prices[chainName] = gasPrice
+	setByChainAndDenom(gp.prices, chainName, denom, gasPrice)
+	setUpdatedAt(gp.pricesUpdatedAt, chainName, denom, updatedAt)
 	return nil
 }
 
-func (gp *InMemoryGasPriceProvider) HasGasFactor(chainName string) (bool, error) {
+func (gp *InMemoryGasPriceProvider) HasGasFactor(chainName, denom string) (bool, error) {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	_, found := gp.factors[chainName]
+	_, found := getByChainAndDenom(gp.factors, chainName, denom)
 	return found, nil
 }
 
-func (gp *InMemoryGasPriceProvider) GetGasFactor(chainName string) (float64, error) {
+func (gp *InMemoryGasPriceProvider) GetGasFactor(chainName, denom string) (float64, error) {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	gasFactor, found := gp.factors[chainName]
+	gasFactor, found := getByChainAndDenom(gp.factors, chainName, denom)
 	if !found {
 		return 0, ErrNoGasFactor
 	}
@@ -115,99 +242,302 @@ func (gp *InMemoryGasPriceProvider) GetGasFactor(chainName string) (float64, err
 	return gasFactor, nil
 }
 
-func (gp *InMemoryGasPriceProvider) SetGasFactor(chainName string, gasFactor float64) error {
+func (gp *InMemoryGasPriceProvider) SetGasFactor(chainName, denom string, gasFactor float64) error {
+	return gp.setGasFactorAt(chainName, denom, gasFactor, time.Now().UTC(), "")
+}
+
+func (gp *InMemoryGasPriceProvider) setGasFactorAt(chainName, denom string, gasFactor float64, updatedAt time.Time, reason string) error {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	gp.factors[chainName] = gasFactor
+	setByChainAndDenom(gp.factors, chainName, denom, gasFactor)
+	setUpdatedAt(gp.factorsUpdatedAt, chainName, denom, updatedAt)
 	return nil
 }
 
-func (gp *InMemoryGasPriceProvider) getGasData() (*GasData, error) {
+func (gp *InMemoryGasPriceProvider) GetGasFactorState(chainName, denom string) (*GasFactorState, error) {
 	gp.lock.Lock()
 	defer gp.lock.Unlock()
 
-	gasPrices := make(map[string]float64)
-	for chainName, price := range gp.prices {
-		gasPrices[chainName] = price
+	gasFactorState, found := getByChainAndDenom(gp.factorStates, chainName, denom)
+	if !found {
+		return nil, ErrNoGasFactorState
 	}
 
-	gasFactors := make(map[string]float64)
-	for chainName, factor := range gp.factors {
-		gasFactors[chainName] = factor
+	return &gasFactorState, nil
+}
+
+func (gp *InMemoryGasPriceProvider) SetGasFactorState(chainName, denom string, gasFactorState *GasFactorState) error {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	setByChainAndDenom(gp.factorStates, chainName, denom, *gasFactorState)
+	return nil
+}
+
+func (gp *InMemoryGasPriceProvider) GetGasStreak(chainName, denom string) (*GasStreak, error) {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	gasStreak, found := getByChainAndDenom(gp.streaks, chainName, denom)
+	if !found {
+		return nil, ErrNoGasStreak
 	}
 
+	return &gasStreak, nil
+}
+
+func (gp *InMemoryGasPriceProvider) SetGasStreak(chainName, denom string, gasStreak *GasStreak) error {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	setByChainAndDenom(gp.streaks, chainName, denom, *gasStreak)
+	return nil
+}
+
+func (gp *InMemoryGasPriceProvider) ResetGas(chainName string) error {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	delete(gp.prices, chainName)
+	delete(gp.pricesUpdatedAt, chainName)
+	delete(gp.factors, chainName)
+	delete(gp.factorsUpdatedAt, chainName)
+	delete(gp.factorStates, chainName)
+	delete(gp.streaks, chainName)
+	return nil
+}
+
+func (gp *InMemoryGasPriceProvider) getGasData() (*GasData, error) {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
 	gasData := &GasData{
-		GasPrices:  gasPrices,
-		GasFactors: gasFactors,
+		GasPrices:       copyByChainAndDenom(gp.prices),
+		GasFactors:      copyByChainAndDenom(gp.factors),
+		GasFactorStates: copyByChainAndDenom(gp.factorStates),
+		GasStreaks:      copyByChainAndDenom(gp.streaks),
+
+		GasPricesUpdatedAt:  copyByChainAndDenom(gp.pricesUpdatedAt),
+		GasFactorsUpdatedAt: copyByChainAndDenom(gp.factorsUpdatedAt),
 	}
 
 	return gasData, nil
 }
 
-// Helper function to know if an error had to do with gas.
-func IsGasRelatedError(codespace string, code uint32) bool {
-	return IsGasPriceError(codespace, code) || isGasAmountError(codespace, code)
+// Whether any value is stored for a chain and denom.
+func (gp *InMemoryGasPriceProvider) hasAny(chainName, denom string) bool {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	_, hasPrice := getByChainAndDenom(gp.prices, chainName, denom)
+	_, hasFactor := getByChainAndDenom(gp.factors, chainName, denom)
+	_, hasFactorState := getByChainAndDenom(gp.factorStates, chainName, denom)
+	_, hasStreak := getByChainAndDenom(gp.streaks, chainName, denom)
+	return hasPrice || hasFactor || hasFactorState || hasStreak
 }
 
-// Helper function to determine if an error is related to too small of a gas price
-func IsGasPriceError(codespace string, code uint32) bool {
-	return (codespace == "sdk" && code == 13) || (codespace == "gaia" && code == 4)
+// Move all values for a chain from one denom to another. Used to migrate legacy data.
+func (gp *InMemoryGasPriceProvider) move(chainName, fromDenom, toDenom string) {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	moveByChainAndDenom(gp.prices, chainName, fromDenom, toDenom)
+	moveByChainAndDenom(gp.factors, chainName, fromDenom, toDenom)
+	moveByChainAndDenom(gp.factorStates, chainName, fromDenom, toDenom)
+	moveByChainAndDenom(gp.streaks, chainName, fromDenom, toDenom)
+	moveByChainAndDenom(gp.pricesUpdatedAt, chainName, fromDenom, toDenom)
+	moveByChainAndDenom(gp.factorsUpdatedAt, chainName, fromDenom, toDenom)
 }
 
-// Helper function to determine if an error is related to to few gas units
-func isGasAmountError(codespace string, code uint32) bool {
-	return (codespace == "sdk" && code == 11)
+// Replace all values with the given data.
+func (gp *InMemoryGasPriceProvider) replace(gasData *GasData) {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
+
+	gp.prices = copyByChainAndDenom(gasData.GasPrices)
+	gp.factors = copyByChainAndDenom(gasData.GasFactors)
+	gp.factorStates = copyByChainAndDenom(gasData.GasFactorStates)
+	gp.streaks = copyByChainAndDenom(gasData.GasStreaks)
+	gp.pricesUpdatedAt = copyByChainAndDenom(gasData.GasPricesUpdatedAt)
+	gp.factorsUpdatedAt = copyByChainAndDenom(gasData.GasFactorsUpdatedAt)
 }
 
-// extractMinGlobalFee is basically a kludge for Evmos, and EIP-1559.
-func extractMinGlobalFee(errMsg string) (float64, error) {
-	// Regular expression to match the desired number
-	pattern := `(\d+)\w+\)\. Please increase`
-	re := regexp.MustCompile(pattern)
+// Restore when prices and factors were last set, for instance after loading them from disk.
+func (gp *InMemoryGasPriceProvider) restoreUpdatedAt(gasData *GasData) {
+	gp.lock.Lock()
+	defer gp.lock.Unlock()
 
-	matches := re.FindStringSubmatch(errMsg)
-	if len(matches) > 1 {
-		converted, err := strconv.Atoi(matches[1])
-		if err != nil {
-			return 0, err
+	gp.pricesUpdatedAt = copyByChainAndDenom(gasData.GasPricesUpdatedAt)
+	gp.factorsUpdatedAt = copyByChainAndDenom(gasData.GasFactorsUpdatedAt)
+}
+
+// Helpers for values keyed by chain name, then denom.
+
+func getByChainAndDenom[V any](values map[string]map[string]V, chainName, denom string) (V, bool) {
+	value, found := values[chainName][denom]
+	return value, found
+}
+
+func setByChainAndDenom[V any](values map[string]map[string]V, chainName, denom string, value V) {
+	_, found := values[chainName]
+	if !found {
+		values[chainName] = make(map[string]V)
+	}
+	values[chainName][denom] = value
+}
+
+func moveByChainAndDenom[V any](values map[string]map[string]V, chainName, fromDenom, toDenom string) {
+	value, found := getByChainAndDenom(values, chainName, fromDenom)
+	if !found {
+		return
+	}
+
+	setByChainAndDenom(values, chainName, toDenom, value)
+	delete(values[chainName], fromDenom)
+}
+
+func removeByChainAndDenom[V any](values map[string]map[string]V, chainName, denom string) {
+	delete(values[chainName], denom)
+	if len(values[chainName]) == 0 {
+		delete(values, chainName)
+	}
+}
+
+// Set when a value was updated, or remove the time if updatedAt is zero.
+func setUpdatedAt(values map[string]map[string]time.Time, chainName, denom string, updatedAt time.Time) {
+	if updatedAt.IsZero() {
+		removeByChainAndDenom(values, chainName, denom)
+		return
+	}
+	setByChainAndDenom(values, chainName, denom, updatedAt)
+}
+
+// Overwrite values in a destination with the values of the given keys in a source. Keys that are not in the source are removed.
+func mergeByChainAndDenom[V any](destination, source map[string]map[string]V, keys map[gasKey]bool) {
+	for key := range keys {
+		value, found := getByChainAndDenom(source, key.chainName, key.denom)
+		if found {
+			setByChainAndDenom(destination, key.chainName, key.denom, value)
+		} else {
+			removeByChainAndDenom(destination, key.chainName, key.denom)
 		}
-		return float64(converted), nil
+	}
+}
 
+func copyByChainAndDenom[V any](values map[string]map[string]V) map[string]map[string]V {
+	copied := make(map[string]map[string]V)
+	for chainName, valuesByDenom := range values {
+		copied[chainName] = make(map[string]V)
+		for denom, value := range valuesByDenom {
+			copied[chainName][denom] = value
+		}
 	}
-	return 0, fmt.Errorf("unrecognized error format")
+	return copied
+}
+
+// Helper function to know if an error had to do with gas. Codes are interpreted with chainName's ABCI registry.
+func IsGasRelatedError(chainName, codespace string, code uint32) bool {
+	return IsGasPriceError(chainName, codespace, code) || isGasAmountError(chainName, codespace, code)
+}
+
+// Helper function to determine if an error is related to too small of a gas price
+func IsGasPriceError(chainName, codespace string, code uint32) bool {
+	return errors.Is(abci.ForChain(chainName).Kind(codespace, code), abci.ErrInsufficientFee)
+}
+
+// Helper function to determine if an error is related to to few gas units
+func isGasAmountError(chainName, codespace string, code uint32) bool {
+	return errors.Is(abci.ForChain(chainName).Kind(codespace, code), abci.ErrOutOfGas)
+}
+
+// IsGasError returns whether an error from a tx result, like those from abci.FromTxResponse, is related to gas.
+func IsGasError(err error) bool {
+	return errors.Is(err, abci.ErrInsufficientFee) || errors.Is(err, abci.ErrOutOfGas)
 }
 
 // FileGasPriceProvider writes gas prices to a file by internally wrapping calls to an InMemoryGasPriceProvider.
+//
+// Writes are crash safe: data is written to a temporary file and renamed into place, and the previous good copy is kept as a backup. Several
+// processes may share a data directory. Writes take an advisory lock on the file, and merge the changes this process made with the data on
+// disk.
 type FileGasPriceProvider struct {
-	wrapped GasPriceProvider
+	wrapped *InMemoryGasPriceProvider
 
 	logger      *log.Logger
 	gasDataFile string
 
+	// Values this process changed since the last write, which take precedence over the data on disk when merging.
+	dirtyPrices       map[gasKey]bool
+	dirtyFactors      map[gasKey]bool
+	dirtyFactorStates map[gasKey]bool
+	dirtyStreaks      map[gasKey]bool
+
 	lock *sync.Mutex
 }
 
 // gasDataFile is the file name inside the data directory
 const gasDataFile = "gas_prices.json"
 
+// Suffixes for files kept alongside the gas data file
+const (
+	gasDataBackupSuffix = ".bak"
+	gasDataLockSuffix   = ".lock"
+)
+
+// Current version of the gas data format. Unversioned files predate versioning, and are migrated when loaded.
+const gasDataVersion = 1
+
+// Gas files written before prices were keyed by denom are migrated under this denom. The first denom requested for a chain adopts them.
+const legacyDenom = ""
+
 // Assert all FileGasPriceProvider are GasPriceProviders
 var _ GasPriceProvider = (*FileGasPriceProvider)(nil)
 
-// Data format for gas file.
+// Data format for gas file. Values are keyed by chain name, then fee denom.
 type GasData struct {
-	GasFactors map[string]float64 `json:"gas_factors"`
-	GasPrices  map[string]float64 `json:"gas_prices"`
+	Version int `json:"version"`
+
+	GasFactors      map[string]map[string]float64        `json:"gas_factors"`
+	GasPrices       map[string]map[string]float64        `json:"gas_prices"`
+	GasFactorStates map[string]map[string]GasFactorState `json:"gas_factor_states,omitempty"`
+	GasStreaks      map[string]map[string]GasStreak      `json:"gas_streaks,omitempty"`
+
+	// When prices and factors were last set. Values written before timestamps were recorded have none.
+	GasPricesUpdatedAt  map[string]map[string]time.Time `json:"gas_prices_updated_at,omitempty"`
+	GasFactorsUpdatedAt map[string]map[string]time.Time `json:"gas_factors_updated_at,omitempty"`
+}
+
+// Data format for gas files written before values were keyed by denom.
+type legacyGasData struct {
+	GasFactors      map[string]float64        `json:"gas_factors"`
+	GasPrices       map[string]float64        `json:"gas_prices"`
+	GasFactorStates map[string]GasFactorState `json:"gas_factor_states,omitempty"`
+}
+
+// GasFactorState is the state of a chain's gas factor adjustments.
+type GasFactorState struct {
+	// Whether a lower gas factor is currently being trialed
+	IsTryingToStepDown bool `json:"is_trying_to_step_down"`
+
+	// Number of consecutive successes required before attempting to step down the gas factor
+	SuccessThreshold int `json:"success_threshold"`
+}
+
+// GasStreak counts consecutive outcomes for a chain and denom, so that adjustments resume where they left off after a restart.
+type GasStreak struct {
+	GasPriceSuccesses int `json:"gas_price_successes"`
+	GasPriceFailures  int `json:"gas_price_failures"`
+
+	GasFactorSuccesses int `json:"gas_factor_successes"`
+	GasFactorFailures  int `json:"gas_factor_failures"`
 }
 
 // Create a new FileGasProvider which will wrap an in-memory gas price provider
 func NewFileGasPriceProvider(logger *log.Logger, dataDirectory string) (GasPriceProvider, error) {
 	// Wrap an in memory provider, so that the logic is reused
 	// TODO: InMemoryGasPriceProvider should probably be renamed to BaseGasPriceProvider
-	wrapped, err := NewInMemoryGasPriceProvider()
-	if err != nil {
-		return nil, err
-	}
+	wrapped := newInMemoryGasPriceProvider()
 
 	// Create a provider
 	gasDataFile := fmt.Sprintf("%s/%s", dataDirectory, gasDataFile)
@@ -215,11 +545,17 @@ func NewFileGasPriceProvider(logger *log.Logger, dataDirectory string) (GasPrice
 		wrapped:     wrapped,
 		logger:      logger,
 		gasDataFile: gasDataFile,
-		lock:        &sync.Mutex{},
+
+		dirtyPrices:       make(map[gasKey]bool),
+		dirtyFactors:      make(map[gasKey]bool),
+		dirtyFactorStates: make(map[gasKey]bool),
+		dirtyStreaks:      make(map[gasKey]bool),
+
+		lock: &sync.Mutex{},
 	}
 
 	// Initialize the wrapped provider.
-	err = provider.initialize()
+	err := provider.initialize()
 	if err != nil {
 		return nil, err
 	}
@@ -227,39 +563,143 @@ func NewFileGasPriceProvider(logger *log.Logger, dataDirectory string) (GasPrice
 	return provider, nil
 }
 
-func (p *FileGasPriceProvider) HasGasPrice(chainName string) (bool, error) {
-	return p.wrapped.HasGasPrice(chainName)
+func (p *FileGasPriceProvider) HasGasPrice(chainName, denom string) (bool, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return false, err
+	}
+
+	return p.wrapped.HasGasPrice(chainName, denom)
 }
 
-func (p *FileGasPriceProvider) GetGasPrice(chainName string) (float64, error) {
-	return p.wrapped.GetGasPrice(chainName)
+func (p *FileGasPriceProvider) GetGasPrice(chainName, denom string) (float64, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.wrapped.GetGasPrice(chainName, denom)
+}
+
+func (p *FileGasPriceProvider) SetGasPrice(chainName, denom string, gasPrice float64) error {
+	return p.setGasPriceAt(chainName, denom, gasPrice, time.Now().UTC(), "")
 }
 
-func (p *FileGasPriceProvider) SetGasPrice(chainName string, gasPrice float64) error {
+func (p *FileGasPriceProvider) setGasPriceAt(chainName, denom string, gasPrice float64, updatedAt time.Time, reason string) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	err := p.wrapped.SetGasPrice(chainName, gasPrice)
+	err := p.wrapped.setGasPriceAt(chainName, denom, gasPrice, updatedAt, reason)
 	if err != nil {
 		return err
 	}
+	p.dirtyPrices[gasKey{chainName: chainName, denom: denom}] = true
 
 	return p.writeToFile()
 }
 
-func (p *FileGasPriceProvider) HasGasFactor(chainName string) (bool, error) {
-	return p.wrapped.HasGasFactor(chainName)
+func (p *FileGasPriceProvider) HasGasFactor(chainName, denom string) (bool, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return false, err
+	}
+
+	return p.wrapped.HasGasFactor(chainName, denom)
+}
+
+func (p *FileGasPriceProvider) GetGasFactor(chainName, denom string) (float64, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.wrapped.GetGasFactor(chainName, denom)
+}
+
+func (p *FileGasPriceProvider) SetGasFactor(chainName, denom string, gasFactor float64) error {
+	return p.setGasFactorAt(chainName, denom, gasFactor, time.Now().UTC(), "")
 }
 
-func (p *FileGasPriceProvider) GetGasFactor(chainName string) (float64, error) {
-	return p.wrapped.GetGasFactor(chainName)
+func (p *FileGasPriceProvider) setGasFactorAt(chainName, denom string, gasFactor float64, updatedAt time.Time, reason string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	err := p.wrapped.setGasFactorAt(chainName, denom, gasFactor, updatedAt, reason)
+	if err != nil {
+		return err
+	}
+	p.dirtyFactors[gasKey{chainName: chainName, denom: denom}] = true
+
+	return p.writeToFile()
+}
+
+func (p *FileGasPriceProvider) GetGasFactorState(chainName, denom string) (*GasFactorState, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.wrapped.GetGasFactorState(chainName, denom)
 }
 
-func (p *FileGasPriceProvider) SetGasFactor(chainName string, gasFactor float64) error {
+func (p *FileGasPriceProvider) SetGasFactorState(chainName, denom string, gasFactorState *GasFactorState) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	err := p.wrapped.SetGasFactor(chainName, gasFactor)
+	err := p.wrapped.SetGasFactorState(chainName, denom, gasFactorState)
+	if err != nil {
+		return err
+	}
+	p.dirtyFactorStates[gasKey{chainName: chainName, denom: denom}] = true
+
+	return p.writeToFile()
+}
+
+func (p *FileGasPriceProvider) GetGasStreak(chainName, denom string) (*GasStreak, error) {
+	err := p.adoptLegacyData(chainName, denom)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.wrapped.GetGasStreak(chainName, denom)
+}
+
+func (p *FileGasPriceProvider) SetGasStreak(chainName, denom string, gasStreak *GasStreak) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	err := p.wrapped.SetGasStreak(chainName, denom, gasStreak)
+	if err != nil {
+		return err
+	}
+	p.dirtyStreaks[gasKey{chainName: chainName, denom: denom}] = true
+
+	return p.writeToFile()
+}
+
+func (p *FileGasPriceProvider) ResetGas(chainName string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// Mark everything being removed as dirty, so that removals are merged into the file
+	gasData, err := p.wrapped.getGasData()
+	if err != nil {
+		return err
+	}
+	for denom := range gasData.GasPrices[chainName] {
+		p.dirtyPrices[gasKey{chainName: chainName, denom: denom}] = true
+	}
+	for denom := range gasData.GasFactors[chainName] {
+		p.dirtyFactors[gasKey{chainName: chainName, denom: denom}] = true
+	}
+	for denom := range gasData.GasFactorStates[chainName] {
+		p.dirtyFactorStates[gasKey{chainName: chainName, denom: denom}] = true
+	}
+	for denom := range gasData.GasStreaks[chainName] {
+		p.dirtyStreaks[gasKey{chainName: chainName, denom: denom}] = true
+	}
+
+	err = p.wrapped.ResetGas(chainName)
 	if err != nil {
 		return err
 	}
@@ -271,22 +711,89 @@ func (p *FileGasPriceProvider) getGasData() (*GasData, error) {
 	return p.wrapped.getGasData()
 }
 
+// If the chain has legacy data, and nothing has been stored for the denom, move the legacy data to the denom.
+func (p *FileGasPriceProvider) adoptLegacyData(chainName, denom string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// Nothing to do if there is no legacy data, or the denom has its own data
+	if !p.wrapped.hasAny(chainName, legacyDenom) || p.wrapped.hasAny(chainName, denom) {
+		return nil
+	}
+
+	p.wrapped.move(chainName, legacyDenom, denom)
+	for _, key := range []gasKey{{chainName: chainName, denom: legacyDenom}, {chainName: chainName, denom: denom}} {
+		p.dirtyPrices[key] = true
+		p.dirtyFactors[key] = true
+		p.dirtyFactorStates[key] = true
+		p.dirtyStreaks[key] = true
+	}
+	p.logger.Info("💾 migrated legacy gas data to denom", "chain_name", chainName, "denom", denom)
+
+	return p.writeToFile()
+}
+
+// Write data to disk, merging with any changes other processes have made. Callers must hold the lock.
 func (p *FileGasPriceProvider) writeToFile() error {
-	gasData, err := p.getGasData()
+	// Lock the file against other processes
+	unlock, err := config.LockFile(p.gasDataFile + gasDataLockSuffix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := unlock()
+		if err != nil {
+			p.logger.Error("failed to unlock gas price file", "file", p.gasDataFile, "error", err.Error())
+		}
+	}()
+
+	// Read what is currently on disk. If it is missing or unreadable, this process' data will replace it.
+	onDisk, onDiskBytes, err := p.readGasDataFile(p.gasDataFile)
+	if err != nil {
+		p.logger.Warn("💾 unable to read gas prices from disk before writing, will overwrite", "file", p.gasDataFile, "error", err.Error())
+		onDisk = nil
+	}
+
+	merged, err := p.getGasData()
 	if err != nil {
 		return err
 	}
+	if onDisk != nil {
+		mergeByChainAndDenom(onDisk.GasPrices, merged.GasPrices, p.dirtyPrices)
+		mergeByChainAndDenom(onDisk.GasFactors, merged.GasFactors, p.dirtyFactors)
+		mergeByChainAndDenom(onDisk.GasFactorStates, merged.GasFactorStates, p.dirtyFactorStates)
+		mergeByChainAndDenom(onDisk.GasStreaks, merged.GasStreaks, p.dirtyStreaks)
+		mergeByChainAndDenom(onDisk.GasPricesUpdatedAt, merged.GasPricesUpdatedAt, p.dirtyPrices)
+		mergeByChainAndDenom(onDisk.GasFactorsUpdatedAt, merged.GasFactorsUpdatedAt, p.dirtyFactors)
+		merged = onDisk
+	}
+	merged.Version = gasDataVersion
 
-	jsonBytes, err := json.MarshalIndent(gasData, "", "    ")
+	jsonBytes, err := json.MarshalIndent(merged, "", "    ")
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(p.gasDataFile, jsonBytes, 0o600)
+	// Keep the last good copy as a backup
+	if onDisk != nil {
+		err = config.AtomicWrite(p.gasDataFile+gasDataBackupSuffix, onDiskBytes, 0o600)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = config.AtomicWrite(p.gasDataFile, jsonBytes, 0o600)
 	if err != nil {
 		return err
 	}
 
+	// Pick up any changes from other processes
+	p.wrapped.replace(merged)
+	p.dirtyPrices = make(map[gasKey]bool)
+	p.dirtyFactors = make(map[gasKey]bool)
+	p.dirtyFactorStates = make(map[gasKey]bool)
+	p.dirtyStreaks = make(map[gasKey]bool)
+
 	p.logger.Info("💾 saved gas prices to disk", "file", p.gasDataFile)
 	return nil
 }
@@ -301,44 +808,171 @@ func (p *FileGasPriceProvider) initialize() error {
 		return err
 	}
 
-	for chainName, gasFactor := range gasData.GasFactors {
-		err := p.wrapped.SetGasFactor(chainName, gasFactor)
-		if err != nil {
-			return err
+	for chainName, gasFactors := range gasData.GasFactors {
+		for denom, gasFactor := range gasFactors {
+			err := p.wrapped.SetGasFactor(chainName, denom, gasFactor)
+			if err != nil {
+				return err
+			}
+			logger.Info("💾 initialized gas factor", "gas_factor", gasFactor, "chain_name", chainName, "denom", denom)
 		}
-		logger.Info("💾 initialized gas factor", "gas_factor", gasFactor, "chain_name", chainName)
 	}
 
-	for chainName, gasPrice := range gasData.GasPrices {
-		err := p.wrapped.SetGasPrice(chainName, gasPrice)
-		if err != nil {
-			return err
+	for chainName, gasPrices := range gasData.GasPrices {
+		for denom, gasPrice := range gasPrices {
+			err := p.wrapped.SetGasPrice(chainName, denom, gasPrice)
+			if err != nil {
+				return err
+			}
+			logger.Info("💾 initialized gas price", "gas_price", gasPrice, "chain_name", chainName, "denom", denom)
+		}
+	}
+
+	for chainName, gasFactorStates := range gasData.GasFactorStates {
+		for denom, gasFactorState := range gasFactorStates {
+			gasFactorState := gasFactorState
+			err := p.wrapped.SetGasFactorState(chainName, denom, &gasFactorState)
+			if err != nil {
+				return err
+			}
+			logger.Info("💾 initialized gas factor state", "is_trying_to_step_down", gasFactorState.IsTryingToStepDown, "success_threshold", gasFactorState.SuccessThreshold, "chain_name", chainName, "denom", denom)
 		}
-		logger.Info("💾 initialized gas price", "gas_price", gasPrice, "chain_name", chainName)
 	}
 
+	for chainName, gasStreaks := range gasData.GasStreaks {
+		for denom, gasStreak := range gasStreaks {
+			gasStreak := gasStreak
+			err := p.wrapped.SetGasStreak(chainName, denom, &gasStreak)
+			if err != nil {
+				return err
+			}
+			logger.Info("💾 initialized gas streak", "gas_price_successes", gasStreak.GasPriceSuccesses, "gas_price_failures", gasStreak.GasPriceFailures, "gas_factor_successes", gasStreak.GasFactorSuccesses, "gas_factor_failures", gasStreak.GasFactorFailures, "chain_name", chainName, "denom", denom)
+		}
+	}
+
+	// Setting values marks them as updated now, so restore when they were really updated
+	p.wrapped.restoreUpdatedAt(gasData)
+
 	logger.Info("gas price state initialization complete")
 	return nil
 }
 
-// Load data from the file
+// Load data from the file, falling back to the backup if the file is unreadable.
 func (p *FileGasPriceProvider) loadData() (*GasData, error) {
-	_, err := os.Stat(p.gasDataFile)
+	gasData, _, err := p.readGasDataFile(p.gasDataFile)
+	if err == nil && gasData != nil {
+		return gasData, nil
+	}
+	if err != nil {
+		p.logger.Error("💾 unable to read gas prices from disk, trying backup", "file", p.gasDataFile, "error", err.Error())
+	}
+
+	backupFile := p.gasDataFile + gasDataBackupSuffix
+	backupData, _, backupErr := p.readGasDataFile(backupFile)
+	if backupErr != nil {
+		p.logger.Error("💾 unable to read gas prices from backup", "file", backupFile, "error", backupErr.Error())
+		if err != nil {
+			return nil, err
+		}
+		return nil, backupErr
+	}
+	if backupData != nil {
+		p.logger.Warn("💾 restored gas prices from backup", "file", backupFile)
+		return backupData, nil
+	}
+
+	// Nothing could be found, so return the original error, if any.
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Info("💾 no gas price cache found on disk. will not initialize", "file", p.gasDataFile)
+	return emptyGasData(), nil
+}
+
+// Read and parse a gas data file. If the file does not exist, returns (nil, nil, nil).
+func (p *FileGasPriceProvider) readGasDataFile(file string) (*GasData, []byte, error) {
+	fileBytes, err := os.ReadFile(file)
 	if os.IsNotExist(err) {
-		p.logger.Info("💾 no gas price cache found on disk. will not initialize", "file", p.gasDataFile)
-		return &GasData{}, nil
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
 	}
 
-	file, err := os.Open(p.gasDataFile)
+	gasData, err := parseGasData(fileBytes, p.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gasData, fileBytes, nil
+}
+
+func emptyGasData() *GasData {
+	return &GasData{
+		Version:         gasDataVersion,
+		GasFactors:      make(map[string]map[string]float64),
+		GasPrices:       make(map[string]map[string]float64),
+		GasFactorStates: make(map[string]map[string]GasFactorState),
+		GasStreaks:      make(map[string]map[string]GasStreak),
+
+		GasPricesUpdatedAt:  make(map[string]map[string]time.Time),
+		GasFactorsUpdatedAt: make(map[string]map[string]time.Time),
+	}
+}
+
+// Parse gas data, migrating from older formats if needed.
+func parseGasData(fileBytes []byte, logger *log.Logger) (*GasData, error) {
+	versioned := &struct {
+		Version int `json:"version"`
+	}{}
+	err := json.Unmarshal(fileBytes, versioned)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	gasData := &GasData{}
-	if err := json.NewDecoder(file).Decode(gasData); err != nil {
+	switch {
+	case versioned.Version == 0:
+		return parseUnversionedGasData(fileBytes, logger)
+	case versioned.Version > gasDataVersion:
+		return nil, fmt.Errorf("gas data version %d is newer than supported version %d", versioned.Version, gasDataVersion)
+	}
+
+	gasData := emptyGasData()
+	err = json.Unmarshal(fileBytes, gasData)
+	if err != nil {
 		return nil, err
 	}
+	return gasData, nil
+}
+
+// Parse gas data written before versioning was added. Values may or may not be keyed by denom.
+func parseUnversionedGasData(fileBytes []byte, logger *log.Logger) (*GasData, error) {
+	gasData := emptyGasData()
+	err := json.Unmarshal(fileBytes, gasData)
+	if err == nil {
+		return gasData, nil
+	}
+
+	// Values in the legacy format are not objects, so fail with a type error.
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return nil, err
+	}
+
+	legacyData := &legacyGasData{}
+	if err := json.Unmarshal(fileBytes, legacyData); err != nil {
+		return nil, err
+	}
+	logger.Info("💾 migrating gas data from legacy format")
+
+	gasData = emptyGasData()
+	for chainName, gasFactor := range legacyData.GasFactors {
+		setByChainAndDenom(gasData.GasFactors, chainName, legacyDenom, gasFactor)
+	}
+	for chainName, gasPrice := range legacyData.GasPrices {
+		setByChainAndDenom(gasData.GasPrices, chainName, legacyDenom, gasPrice)
+	}
+	for chainName, gasFactorState := range legacyData.GasFactorStates {
+		setByChainAndDenom(gasData.GasFactorStates, chainName, legacyDenom, gasFactorState)
+	}
 
 	return gasData, nil
 }
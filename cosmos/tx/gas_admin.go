@@ -0,0 +1,393 @@
+package tx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+// GasPins are gas prices and factors which operators have pinned. Gas managers created with the pins do not adjust or override pinned values.
+//
+// A nil *GasPins has nothing pinned.
+type GasPins struct {
+	prices  map[gasKey]bool
+	factors map[gasKey]bool
+
+	lock *sync.RWMutex
+}
+
+func NewGasPins() *GasPins {
+	return &GasPins{
+		prices:  make(map[gasKey]bool),
+		factors: make(map[gasKey]bool),
+
+		lock: &sync.RWMutex{},
+	}
+}
+
+func (p *GasPins) PinGasPrice(chainName, denom string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prices[gasKey{chainName: chainName, denom: denom}] = true
+}
+
+func (p *GasPins) PinGasFactor(chainName, denom string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.factors[gasKey{chainName: chainName, denom: denom}] = true
+}
+
+// Unpin the gas price and factor for a chain and denom.
+func (p *GasPins) Unpin(chainName, denom string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := gasKey{chainName: chainName, denom: denom}
+	delete(p.prices, key)
+	delete(p.factors, key)
+}
+
+// Unpin gas prices and factors for all of a chain's denoms.
+func (p *GasPins) UnpinChain(chainName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key := range p.prices {
+		if key.chainName == chainName {
+			delete(p.prices, key)
+		}
+	}
+	for key := range p.factors {
+		if key.chainName == chainName {
+			delete(p.factors, key)
+		}
+	}
+}
+
+func (p *GasPins) IsGasPricePinned(chainName, denom string) bool {
+	if p == nil {
+		return false
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.prices[gasKey{chainName: chainName, denom: denom}]
+}
+
+func (p *GasPins) IsGasFactorPinned(chainName, denom string) bool {
+	if p == nil {
+		return false
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.factors[gasKey{chainName: chainName, denom: denom}]
+}
+
+// GasAdminState is the state of gas for a chain and denom, as reported by the gas admin handler.
+type GasAdminState struct {
+	ChainName string `json:"chain_name"`
+	Denom     string `json:"denom"`
+
+	GasPrice       *float64        `json:"gas_price,omitempty"`
+	GasFactor      *float64        `json:"gas_factor,omitempty"`
+	GasFactorState *GasFactorState `json:"gas_factor_state,omitempty"`
+	GasStreak      *GasStreak      `json:"gas_streak,omitempty"`
+
+	GasPricePinned  bool `json:"gas_price_pinned"`
+	GasFactorPinned bool `json:"gas_factor_pinned"`
+
+	// The most recent adjustment, if the provider keeps history
+	LastChange *GasChange `json:"last_change,omitempty"`
+}
+
+// Request bodies for the gas admin handler
+type gasAdminValueRequest struct {
+	ChainName string  `json:"chain_name"`
+	Denom     string  `json:"denom"`
+	Value     float64 `json:"value"`
+	Pin       bool    `json:"pin"`
+}
+
+type gasAdminUnpinRequest struct {
+	ChainName string `json:"chain_name"`
+	Denom     string `json:"denom"`
+}
+
+type gasAdminResetRequest struct {
+	ChainName string `json:"chain_name"`
+}
+
+// Reason recorded in history for changes made through the gas admin handler
+const gasAdminReason = "set by operator"
+
+// Gas admin HTTP handler.
+//
+// Routes:
+//   - GET  /state   List gas state for all chains, or one chain with ?chain_name=
+//   - POST /price   Set a gas price. Body: {"chain_name", "denom", "value", "pin"}
+//   - POST /factor  Set a gas factor. Body: {"chain_name", "denom", "value", "pin"}
+//   - POST /unpin   Unpin a chain and denom's gas price and factor. Body: {"chain_name", "denom"}
+//   - POST /reset   Unpin a chain, and reset its gas prices, factors, factor states and streaks to defaults. Body: {"chain_name"}
+//
+// Mount with http.StripPrefix to serve under a path.
+type gasAdminHandler struct {
+	gasPriceProvider GasPriceProvider
+	pins             *GasPins
+	logger           *log.Logger
+
+	mux *http.ServeMux
+}
+
+var _ http.Handler = (*gasAdminHandler)(nil)
+
+// NewGasAdminHandler creates a handler to inspect and override gas in gasPriceProvider.
+//
+// Pins are only honored by gas managers created with the same pins. See WithGasPins, NewFeeMarketGasManager, NewMarketGasManager and
+// NewLearnedGasFactorManager. After a reset, geometric gas managers price txs from the price they were initialized with, or the chain's
+// min gas price if they were not initialized.
+func NewGasAdminHandler(gasPriceProvider GasPriceProvider, pins *GasPins, logger *log.Logger) (http.Handler, error) {
+	if pins == nil {
+		return nil, fmt.Errorf("gas admin handler requires gas pins")
+	}
+
+	handler := &gasAdminHandler{
+		gasPriceProvider: gasPriceProvider,
+		pins:             pins,
+		logger:           logger.ApplyPrefix("⛽️"),
+
+		mux: http.NewServeMux(),
+	}
+
+	handler.mux.HandleFunc("/state", handler.handleState)
+	handler.mux.HandleFunc("/price", handler.handlePrice)
+	handler.mux.HandleFunc("/factor", handler.handleFactor)
+	handler.mux.HandleFunc("/unpin", handler.handleUnpin)
+	handler.mux.HandleFunc("/reset", handler.handleReset)
+
+	return handler, nil
+}
+
+func (h *gasAdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *gasAdminHandler) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeGasAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		return
+	}
+
+	states, err := h.states(r.URL.Query().Get("chain_name"))
+	if err != nil {
+		writeGasAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeGasAdminResponse(w, states)
+}
+
+func (h *gasAdminHandler) handlePrice(w http.ResponseWriter, r *http.Request) {
+	request := &gasAdminValueRequest{}
+	if !readGasAdminRequest(w, r, request) {
+		return
+	}
+	if request.ChainName == "" || request.Value < 0 {
+		writeGasAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: chain_name is required and value must be >= 0"))
+		return
+	}
+
+	err := setGasPriceWithReason(h.gasPriceProvider, request.ChainName, request.Denom, request.Value, gasAdminReason)
+	if err != nil {
+		writeGasAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if request.Pin {
+		h.pins.PinGasPrice(request.ChainName, request.Denom)
+	}
+	h.logger.Info("operator set gas price", "chain_name", request.ChainName, "denom", request.Denom, "gas_price", request.Value, "pin", request.Pin)
+
+	h.respondWithState(w, request.ChainName)
+}
+
+func (h *gasAdminHandler) handleFactor(w http.ResponseWriter, r *http.Request) {
+	request := &gasAdminValueRequest{}
+	if !readGasAdminRequest(w, r, request) {
+		return
+	}
+	if request.ChainName == "" || request.Value <= 0 {
+		writeGasAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: chain_name is required and value must be > 0"))
+		return
+	}
+
+	err := setGasFactorWithReason(h.gasPriceProvider, request.ChainName, request.Denom, request.Value, gasAdminReason)
+	if err != nil {
+		writeGasAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if request.Pin {
+		h.pins.PinGasFactor(request.ChainName, request.Denom)
+	}
+	h.logger.Info("operator set gas factor", "chain_name", request.ChainName, "denom", request.Denom, "gas_factor", request.Value, "pin", request.Pin)
+
+	h.respondWithState(w, request.ChainName)
+}
+
+func (h *gasAdminHandler) handleUnpin(w http.ResponseWriter, r *http.Request) {
+	request := &gasAdminUnpinRequest{}
+	if !readGasAdminRequest(w, r, request) {
+		return
+	}
+	if request.ChainName == "" {
+		writeGasAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: chain_name is required"))
+		return
+	}
+
+	h.pins.Unpin(request.ChainName, request.Denom)
+	h.logger.Info("operator unpinned gas", "chain_name", request.ChainName, "denom", request.Denom)
+
+	h.respondWithState(w, request.ChainName)
+}
+
+func (h *gasAdminHandler) handleReset(w http.ResponseWriter, r *http.Request) {
+	request := &gasAdminResetRequest{}
+	if !readGasAdminRequest(w, r, request) {
+		return
+	}
+	if request.ChainName == "" {
+		writeGasAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request: chain_name is required"))
+		return
+	}
+
+	h.pins.UnpinChain(request.ChainName)
+	err := h.gasPriceProvider.ResetGas(request.ChainName)
+	if err != nil {
+		writeGasAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.logger.Info("operator reset gas to defaults", "chain_name", request.ChainName)
+
+	h.respondWithState(w, request.ChainName)
+}
+
+// Helpers
+
+// Gather state for a chain, or all chains if chainName is empty.
+func (h *gasAdminHandler) states(chainName string) ([]*GasAdminState, error) {
+	gasData, err := h.gasPriceProvider.getGasData()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect every chain and denom with any data
+	states := make(map[gasKey]*GasAdminState)
+	stateFor := func(key gasKey) *GasAdminState {
+		state, found := states[key]
+		if !found {
+			state = &GasAdminState{
+				ChainName:       key.chainName,
+				Denom:           key.denom,
+				GasPricePinned:  h.pins.IsGasPricePinned(key.chainName, key.denom),
+				GasFactorPinned: h.pins.IsGasFactorPinned(key.chainName, key.denom),
+			}
+			states[key] = state
+		}
+		return state
+	}
+	for chain, prices := range gasData.GasPrices {
+		for denom, price := range prices {
+			price := price
+			stateFor(gasKey{chainName: chain, denom: denom}).GasPrice = &price
+		}
+	}
+	for chain, factors := range gasData.GasFactors {
+		for denom, factor := range factors {
+			factor := factor
+			stateFor(gasKey{chainName: chain, denom: denom}).GasFactor = &factor
+		}
+	}
+	for chain, factorStates := range gasData.GasFactorStates {
+		for denom, factorState := range factorStates {
+			factorState := factorState
+			stateFor(gasKey{chainName: chain, denom: denom}).GasFactorState = &factorState
+		}
+	}
+	for chain, streaks := range gasData.GasStreaks {
+		for denom, streak := range streaks {
+			streak := streak
+			stateFor(gasKey{chainName: chain, denom: denom}).GasStreak = &streak
+		}
+	}
+
+	// Filter, attach history and sort
+	historyProvider, hasHistory := h.gasPriceProvider.(GasHistoryProvider)
+	result := []*GasAdminState{}
+	for key, state := range states {
+		if chainName != "" && key.chainName != chainName {
+			continue
+		}
+
+		if hasHistory {
+			state.LastChange, err = historyProvider.GetLatestGasChange(GasHistoryQuery{ChainName: key.chainName, Denom: key.denom})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, state)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChainName != result[j].ChainName {
+			return result[i].ChainName < result[j].ChainName
+		}
+		return result[i].Denom < result[j].Denom
+	})
+
+	return result, nil
+}
+
+func (h *gasAdminHandler) respondWithState(w http.ResponseWriter, chainName string) {
+	states, err := h.states(chainName)
+	if err != nil {
+		writeGasAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeGasAdminResponse(w, states)
+}
+
+// Read a JSON body from a POST. Writes an error and returns false if the request is invalid.
+func readGasAdminRequest(w http.ResponseWriter, r *http.Request, request any) bool {
+	if r.Method != http.MethodPost {
+		writeGasAdminError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		return false
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(request)
+	if err != nil {
+		writeGasAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeGasAdminResponse(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func writeGasAdminError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
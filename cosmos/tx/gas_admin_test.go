@@ -0,0 +1,123 @@
+package tx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func postGasAdmin(t *testing.T, handler http.Handler, path, body string) []*tx.GasAdminState {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	states := []*tx.GasAdminState{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &states))
+	return states
+}
+
+func TestGasAdminHandler_PinnedPricesAreNotAdjusted(t *testing.T) {
+	provider, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer provider.Close()
+
+	pins := tx.NewGasPins()
+	gasManager, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default(), tx.WithGasPins(pins))
+	require.NoError(t, err)
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", 0.1))
+
+	handler, err := tx.NewGasAdminHandler(provider, pins, log.Default())
+	require.NoError(t, err)
+
+	states := postGasAdmin(t, handler, "/price", `{"chain_name": "chain", "denom": "ufoo", "value": 0.5, "pin": true}`)
+	require.Len(t, states, 1)
+	require.Equal(t, 0.5, *states[0].GasPrice)
+	require.True(t, states[0].GasPricePinned)
+	require.Equal(t, "set by operator", states[0].LastChange.Reason)
+
+	// Failures do not move a pinned price
+	require.NoError(t, gasManager.ManageInclusionFailure("chain", "ufoo"))
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.5, gasPrice)
+
+	// Once unpinned, they do
+	postGasAdmin(t, handler, "/unpin", `{"chain_name": "chain", "denom": "ufoo"}`)
+	require.NoError(t, gasManager.ManageInclusionFailure("chain", "ufoo"))
+	gasPrice, err = gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Greater(t, gasPrice, 0.5)
+}
+
+func TestGasAdminHandler_ResetsChain(t *testing.T) {
+	provider, err := tx.NewFileGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	pins := tx.NewGasPins()
+	gasManager, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default(), tx.WithGasPins(pins))
+	require.NoError(t, err)
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", 0.1))
+
+	handler, err := tx.NewGasAdminHandler(provider, pins, log.Default())
+	require.NoError(t, err)
+
+	postGasAdmin(t, handler, "/price", `{"chain_name": "chain", "denom": "ufoo", "value": 0.5}`)
+	postGasAdmin(t, handler, "/factor", `{"chain_name": "chain", "denom": "ufoo", "value": 1.5, "pin": true}`)
+	states := postGasAdmin(t, handler, "/reset", `{"chain_name": "chain"}`)
+	require.Empty(t, states)
+	require.False(t, pins.IsGasFactorPinned("chain", "ufoo"))
+
+	// Prices go back to the initialized price, and factors to the default
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.1, gasPrice)
+	gasFactor, err := gasManager.GetGasFactor("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 1.1, gasFactor)
+
+	// Bad requests are rejected
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/factor", strings.NewReader(`{"chain_name": "chain", "value": -1}`)))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/state?chain_name=other", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "[]\n", recorder.Body.String())
+}
+
+func TestGasAdminHandler_FeeMarketGasManagerHonorsPins(t *testing.T) {
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	pins := tx.NewGasPins()
+	wrapped, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default(), tx.WithGasPins(pins))
+	require.NoError(t, err)
+
+	rpcClient := &baseFeeRpcClient{baseFee: sdk.NewDec(2)}
+	gasManager, err := tx.NewFeeMarketGasManager("chain", 1, 0, time.Hour, rpcClient, wrapped, pins, log.Default())
+	require.NoError(t, err)
+
+	handler, err := tx.NewGasAdminHandler(provider, pins, log.Default())
+	require.NoError(t, err)
+	postGasAdmin(t, handler, "/price", `{"chain_name": "chain", "denom": "ufoo", "value": 0.5, "pin": true}`)
+
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.5, gasPrice)
+
+	// Once unpinned, prices come from the base fee again
+	postGasAdmin(t, handler, "/unpin", `{"chain_name": "chain", "denom": "ufoo"}`)
+	gasPrice, err = gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 2.0, gasPrice)
+}
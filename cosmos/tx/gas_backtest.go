@@ -0,0 +1,237 @@
+package tx
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// Gas manager backtesting
+//
+// Replays a sequence of tx outcomes against a GasManager, so that gas managers and their parameters can be compared offline. Each step,
+// the gas manager is asked for a price and factor, a tx is "sent", and the outcome is fed back to the gas manager.
+
+// BacktestOutcome is the result of sending a tx in a backtest.
+type BacktestOutcome string
+
+const (
+	// The outcome is derived from the step's market conditions: the tx is rejected if its price is below the required price, and runs
+	// out of gas if it wanted less gas than it used.
+	BacktestOutcomeFromMarket BacktestOutcome = ""
+
+	BacktestOutcomeAccepted         BacktestOutcome = "accepted"
+	BacktestOutcomeRejectedForPrice BacktestOutcome = "rejected_for_price"
+	BacktestOutcomeOutOfGas         BacktestOutcome = "out_of_gas"
+	BacktestOutcomeNotIncluded      BacktestOutcome = "not_included"
+)
+
+// Simulated gas for steps which do not specify it
+const defaultBacktestSimulatedGas int64 = 100_000
+
+// Relative distance from the final price within which prices are considered converged
+const defaultBacktestConvergenceTolerance = 0.05
+
+// BacktestStep is a single tx in a backtest.
+type BacktestStep struct {
+	// A recorded outcome. If empty, the outcome is derived from the market conditions below.
+	Outcome BacktestOutcome `json:"outcome,omitempty"`
+
+	// The lowest gas price the chain accepts. Zero accepts any price.
+	RequiredGasPrice float64 `json:"required_gas_price,omitempty"`
+
+	// Gas from simulating the tx, and gas actually used. Default to defaultBacktestSimulatedGas, and the simulated gas, respectively.
+	SimulatedGas int64 `json:"simulated_gas,omitempty"`
+	GasUsed      int64 `json:"gas_used,omitempty"`
+}
+
+// BacktestConfig parameterizes a backtest.
+type BacktestConfig struct {
+	ChainName string
+	Denom     string
+
+	// Relative distance from the final price within which prices are considered converged. Defaults to 5%.
+	ConvergenceTolerance float64
+}
+
+// BacktestResult reports how a gas manager performed.
+type BacktestResult struct {
+	Steps int
+
+	// Counts of each outcome
+	Accepted         int
+	RejectedForPrice int
+	OutOfGas         int
+	NotIncluded      int
+
+	// Fees paid by txs which landed on chain, including txs which ran out of gas
+	TotalFeesPaid float64
+
+	// Fraction of steps which did not result in an accepted tx
+	FailureRate float64
+
+	// The first step from which the gas price stayed within tolerance of the final price. Equal to Steps if the price had not converged by
+	// the end of the backtest, and -1 if there were no steps.
+	ConvergenceStep int
+
+	FinalGasPrice  float64
+	FinalGasFactor float64
+
+	// The gas price used at each step
+	GasPrices []float64
+}
+
+// Backtest replays steps against a gas manager.
+func Backtest(gasManager GasManager, config BacktestConfig, steps []BacktestStep) (*BacktestResult, error) {
+	tolerance := config.ConvergenceTolerance
+	if tolerance == 0 {
+		tolerance = defaultBacktestConvergenceTolerance
+	}
+
+	result := &BacktestResult{
+		Steps:           len(steps),
+		ConvergenceStep: -1,
+		GasPrices:       make([]float64, 0, len(steps)),
+	}
+
+	for i, step := range steps {
+		gasPrice, err := gasManager.GetGasPrice(config.ChainName, config.Denom)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		gasFactor, err := gasManager.GetGasFactor(config.ChainName, config.Denom)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		result.GasPrices = append(result.GasPrices, gasPrice)
+
+		simulatedGas := step.SimulatedGas
+		if simulatedGas == 0 {
+			simulatedGas = defaultBacktestSimulatedGas
+		}
+		gasUsed := step.GasUsed
+		if gasUsed == 0 {
+			gasUsed = simulatedGas
+		}
+		gasWanted := int64(math.Ceil(float64(simulatedGas) * gasFactor))
+		fee := gasPrice * float64(gasWanted)
+
+		outcome := step.Outcome
+		if outcome == BacktestOutcomeFromMarket {
+			outcome = marketOutcome(step, gasPrice, gasWanted, gasUsed)
+		}
+
+		err = applyBacktestOutcome(gasManager, config, step, outcome, gasWanted, gasUsed, fee)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+
+		switch outcome {
+		case BacktestOutcomeAccepted:
+			result.Accepted++
+			result.TotalFeesPaid += fee
+		case BacktestOutcomeOutOfGas:
+			result.OutOfGas++
+			result.TotalFeesPaid += fee
+		case BacktestOutcomeRejectedForPrice:
+			result.RejectedForPrice++
+		case BacktestOutcomeNotIncluded:
+			result.NotIncluded++
+		}
+	}
+
+	var err error
+	result.FinalGasPrice, err = gasManager.GetGasPrice(config.ChainName, config.Denom)
+	if err != nil {
+		return nil, err
+	}
+	result.FinalGasFactor, err = gasManager.GetGasFactor(config.ChainName, config.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(steps) > 0 {
+		result.FailureRate = float64(len(steps)-result.Accepted) / float64(len(steps))
+		result.ConvergenceStep = convergenceStep(result.GasPrices, result.FinalGasPrice, tolerance)
+	}
+
+	return result, nil
+}
+
+// Determine the outcome of a step from its market conditions.
+func marketOutcome(step BacktestStep, gasPrice float64, gasWanted, gasUsed int64) BacktestOutcome {
+	if gasPrice < step.RequiredGasPrice {
+		return BacktestOutcomeRejectedForPrice
+	}
+	if gasUsed > gasWanted {
+		return BacktestOutcomeOutOfGas
+	}
+	return BacktestOutcomeAccepted
+}
+
+// Give a gas manager the feedback it would receive for an outcome.
+func applyBacktestOutcome(gasManager GasManager, config BacktestConfig, step BacktestStep, outcome BacktestOutcome, gasWanted, gasUsed int64, fee float64) error {
+	switch outcome {
+	case BacktestOutcomeAccepted:
+		txStatus := &txtypes.GetTxResponse{
+			TxResponse: &sdk.TxResponse{Code: 0, GasWanted: gasWanted, GasUsed: gasUsed},
+		}
+		return gasManager.ManageIncludedTransactionStatus(config.ChainName, config.Denom, txStatus)
+	case BacktestOutcomeOutOfGas:
+		txStatus := &txtypes.GetTxResponse{
+			TxResponse: &sdk.TxResponse{
+				Codespace: "sdk",
+				Code:      11,
+				GasWanted: gasWanted,
+				GasUsed:   gasWanted,
+				RawLog:    fmt.Sprintf("out of gas in location: backtest; gasWanted: %d, gasUsed: %d: out of gas", gasWanted, gasUsed),
+			},
+		}
+		return gasManager.ManageIncludedTransactionStatus(config.ChainName, config.Denom, txStatus)
+	case BacktestOutcomeRejectedForPrice:
+		// Report the required fee in the SDK's format, if it is known, so that gas managers which parse fees can use it.
+		rawLog := "insufficient fee"
+		if step.RequiredGasPrice > 0 {
+			requiredFee := int64(math.Ceil(step.RequiredGasPrice * float64(gasWanted)))
+			rawLog = fmt.Sprintf("insufficient fees; got: %d%s required: %d%s: insufficient fee", int64(fee), config.Denom, requiredFee, config.Denom)
+		}
+		broadcastResult := &txtypes.BroadcastTxResponse{
+			TxResponse: &sdk.TxResponse{Codespace: "sdk", Code: 13, GasWanted: gasWanted, RawLog: rawLog},
+		}
+		return gasManager.ManageFailingBroadcastResult(config.ChainName, config.Denom, broadcastResult)
+	case BacktestOutcomeNotIncluded:
+		return gasManager.ManageInclusionFailure(config.ChainName, config.Denom)
+	}
+
+	return fmt.Errorf("unknown backtest outcome: %s", outcome)
+}
+
+// Find the first step from which all prices are within tolerance of the final price.
+func convergenceStep(gasPrices []float64, finalGasPrice, tolerance float64) int {
+	convergedFrom := len(gasPrices)
+	for i := len(gasPrices) - 1; i >= 0; i-- {
+		if math.Abs(gasPrices[i]-finalGasPrice) > tolerance*finalGasPrice {
+			break
+		}
+		convergedFrom = i
+	}
+	return convergedFrom
+}
+
+// SyntheticBacktestSteps generates steps where the required gas price follows a random walk from basePrice. Each step, the required price
+// moves by up to volatility, relative to its current value, and never drops below minPrice. Seeds are used so runs are reproducible.
+func SyntheticBacktestSteps(count int, basePrice, minPrice, volatility float64, seed int64) []BacktestStep {
+	random := rand.New(rand.NewSource(seed)) // #nosec G404 -- backtests do not need secure randomness
+
+	steps := make([]BacktestStep, 0, count)
+	requiredGasPrice := basePrice
+	for i := 0; i < count; i++ {
+		steps = append(steps, BacktestStep{RequiredGasPrice: requiredGasPrice})
+
+		requiredGasPrice *= 1 + volatility*(2*random.Float64()-1)
+		requiredGasPrice = math.Max(requiredGasPrice, minPrice)
+	}
+	return steps
+}
@@ -0,0 +1,74 @@
+package tx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+func newBacktestGasManager(t *testing.T, initialPrice float64) tx.GasManager {
+	t.Helper()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	gasManager, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", initialPrice))
+
+	return gasManager
+}
+
+func TestBacktest_RecordedOutcomes(t *testing.T) {
+	gasManager := newBacktestGasManager(t, 0.01)
+
+	steps := []tx.BacktestStep{
+		{Outcome: tx.BacktestOutcomeNotIncluded},
+		{Outcome: tx.BacktestOutcomeAccepted},
+		{Outcome: tx.BacktestOutcomeOutOfGas},
+		{Outcome: tx.BacktestOutcomeAccepted},
+	}
+	result, err := tx.Backtest(gasManager, tx.BacktestConfig{ChainName: "chain", Denom: "ufoo"}, steps)
+	require.NoError(t, err)
+
+	require.Equal(t, 4, result.Steps)
+	require.Equal(t, 2, result.Accepted)
+	require.Equal(t, 1, result.OutOfGas)
+	require.Equal(t, 1, result.NotIncluded)
+	require.Equal(t, 0.5, result.FailureRate)
+	require.Len(t, result.GasPrices, 4)
+	require.Greater(t, result.TotalFeesPaid, 0.0)
+
+	// The price rose after the inclusion failure, and the factor after running out of gas
+	require.Greater(t, result.GasPrices[1], result.GasPrices[0])
+	require.Greater(t, result.FinalGasFactor, 1.1)
+}
+
+func TestBacktest_ConvergesToMarketPrice(t *testing.T) {
+	gasManager := newBacktestGasManager(t, 0.001)
+
+	steps := make([]tx.BacktestStep, 50)
+	for i := range steps {
+		steps[i] = tx.BacktestStep{RequiredGasPrice: 0.025}
+	}
+	result, err := tx.Backtest(gasManager, tx.BacktestConfig{ChainName: "chain", Denom: "ufoo"}, steps)
+	require.NoError(t, err)
+
+	// The chain's required fee is parsed from rejections, and then the price only probes slightly below it.
+	require.GreaterOrEqual(t, result.RejectedForPrice, 1)
+	require.Greater(t, result.ConvergenceStep, 0)
+	require.Less(t, result.ConvergenceStep, result.Steps)
+	require.InEpsilon(t, 0.025, result.FinalGasPrice, 0.05)
+}
+
+func TestSyntheticBacktestSteps_AreReproducible(t *testing.T) {
+	first := tx.SyntheticBacktestSteps(20, 0.025, 0.01, 0.1, 42)
+	second := tx.SyntheticBacktestSteps(20, 0.025, 0.01, 0.1, 42)
+
+	require.Len(t, first, 20)
+	require.Equal(t, first, second)
+	for _, step := range first {
+		require.GreaterOrEqual(t, step.RequiredGasPrice, 0.01)
+	}
+}
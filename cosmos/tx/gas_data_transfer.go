@@ -0,0 +1,262 @@
+package tx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/config"
+)
+
+// Gas data transfer
+//
+// Gas data can be exported from one GasPriceProvider and imported into another, for instance to seed a new deployment with what an
+// existing one has learned, or to move between file and bolt storage. Exports include when each price and factor was last set, so that
+// imports can prefer newer values.
+
+// Version of the export format
+const gasDataExportVersion = 1
+
+// Reason recorded in gas history for imported values
+const importedGasReason = "imported"
+
+// GasDataExport is a snapshot of a GasPriceProvider.
+type GasDataExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+
+	GasData *GasData `json:"gas_data"`
+}
+
+// ExportGasData takes a snapshot of everything a provider knows.
+func ExportGasData(provider GasPriceProvider) (*GasDataExport, error) {
+	gasData, err := provider.getGasData()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GasDataExport{
+		Version:    gasDataExportVersion,
+		ExportedAt: time.Now().UTC(),
+		GasData:    gasData,
+	}, nil
+}
+
+// WriteGasDataExport writes an export to a file as JSON.
+func WriteGasDataExport(export *GasDataExport, filename string) error {
+	bytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.AtomicWrite(filename, bytes, 0o600)
+}
+
+// ReadGasDataExport reads an export written by WriteGasDataExport.
+func ReadGasDataExport(filename string) (*GasDataExport, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &GasDataExport{}
+	err = json.Unmarshal(bytes, export)
+	if err != nil {
+		return nil, err
+	}
+	if export.Version > gasDataExportVersion {
+		return nil, fmt.Errorf("gas data export version %d is newer than the supported version %d", export.Version, gasDataExportVersion)
+	}
+	if export.GasData == nil {
+		return nil, fmt.Errorf("gas data export has no gas data")
+	}
+	return export, nil
+}
+
+// GasMergePolicy decides whether an imported value replaces a value the provider already has. Values the provider does not have are
+// always imported.
+type GasMergePolicy string
+
+const (
+	// Import values which were set more recently than the provider's. Imported values without a timestamp never replace existing values.
+	NewestWinsGasMergePolicy GasMergePolicy = "newest_wins"
+
+	// Import the larger of the two values. This is the conservative choice: txs overpay rather than fail.
+	MaxPriceGasMergePolicy GasMergePolicy = "max_price"
+
+	// Always import values.
+	OverwriteGasMergePolicy GasMergePolicy = "overwrite"
+)
+
+// GasImportOptions configures ImportGasData.
+type GasImportOptions struct {
+	// The policy for chains without their own policy. Defaults to NewestWinsGasMergePolicy.
+	Policy GasMergePolicy
+
+	// Only import these chains. Empty imports every chain.
+	Chains []string
+
+	// Policies for individual chains, keyed by chain name
+	ChainPolicies map[string]GasMergePolicy
+}
+
+// GasImportResult counts what an import did.
+type GasImportResult struct {
+	ImportedPrices  int
+	ImportedFactors int
+
+	// Values which were kept because of the merge policy
+	SkippedPrices  int
+	SkippedFactors int
+}
+
+// ImportGasData merges an export into a provider.
+//
+// Gas factor states are imported along with their gas factors. Streaks are not imported, since they describe recent outcomes in the
+// exporting deployment. Imported values keep the times they were originally set, so exporting them again, or importing them back into
+// the exporting provider, does not make them look newer than they are.
+func ImportGasData(provider GasPriceProvider, export *GasDataExport, options GasImportOptions) (*GasImportResult, error) {
+	if export == nil || export.GasData == nil {
+		return nil, fmt.Errorf("gas data export has no gas data")
+	}
+	for _, policy := range append(policiesOf(options.ChainPolicies), options.Policy) {
+		if !isValidGasMergePolicy(policy) {
+			return nil, fmt.Errorf("unknown gas merge policy: %s", policy)
+		}
+	}
+
+	existing, err := provider.getGasData()
+	if err != nil {
+		return nil, err
+	}
+	imported := export.GasData
+	result := &GasImportResult{}
+
+	for _, chainName := range sortedKeys(imported.GasPrices) {
+		if !options.includesChain(chainName) {
+			continue
+		}
+		policy := options.policyFor(chainName)
+
+		for _, denom := range sortedKeys(imported.GasPrices[chainName]) {
+			gasPrice := imported.GasPrices[chainName][denom]
+			if !shouldImport(policy, existing.GasPrices, imported.GasPrices, existing.GasPricesUpdatedAt, imported.GasPricesUpdatedAt, chainName, denom) {
+				result.SkippedPrices++
+				continue
+			}
+
+			updatedAt, _ := getByChainAndDenom(imported.GasPricesUpdatedAt, chainName, denom)
+			err := provider.setGasPriceAt(chainName, denom, gasPrice, updatedAt, importedGasReason)
+			if err != nil {
+				return nil, err
+			}
+			result.ImportedPrices++
+		}
+	}
+
+	for _, chainName := range sortedKeys(imported.GasFactors) {
+		if !options.includesChain(chainName) {
+			continue
+		}
+		policy := options.policyFor(chainName)
+
+		for _, denom := range sortedKeys(imported.GasFactors[chainName]) {
+			gasFactor := imported.GasFactors[chainName][denom]
+			if !shouldImport(policy, existing.GasFactors, imported.GasFactors, existing.GasFactorsUpdatedAt, imported.GasFactorsUpdatedAt, chainName, denom) {
+				result.SkippedFactors++
+				continue
+			}
+
+			updatedAt, _ := getByChainAndDenom(imported.GasFactorsUpdatedAt, chainName, denom)
+			err := provider.setGasFactorAt(chainName, denom, gasFactor, updatedAt, importedGasReason)
+			if err != nil {
+				return nil, err
+			}
+
+			gasFactorState, found := getByChainAndDenom(imported.GasFactorStates, chainName, denom)
+			if found {
+				err = provider.SetGasFactorState(chainName, denom, &gasFactorState)
+				if err != nil {
+					return nil, err
+				}
+			}
+			result.ImportedFactors++
+		}
+	}
+
+	return result, nil
+}
+
+// Decide whether an imported value replaces an existing one.
+func shouldImport(
+	policy GasMergePolicy,
+	existingValues, importedValues map[string]map[string]float64,
+	existingUpdatedAt, importedUpdatedAt map[string]map[string]time.Time,
+	chainName, denom string,
+) bool {
+	existingValue, found := getByChainAndDenom(existingValues, chainName, denom)
+	if !found {
+		return true
+	}
+	importedValue, _ := getByChainAndDenom(importedValues, chainName, denom)
+
+	switch policy {
+	case OverwriteGasMergePolicy:
+		return true
+	case MaxPriceGasMergePolicy:
+		return importedValue > existingValue
+	default:
+		importedTime, _ := getByChainAndDenom(importedUpdatedAt, chainName, denom)
+		existingTime, _ := getByChainAndDenom(existingUpdatedAt, chainName, denom)
+		return importedTime.After(existingTime)
+	}
+}
+
+func (o *GasImportOptions) includesChain(chainName string) bool {
+	if len(o.Chains) == 0 {
+		return true
+	}
+	for _, included := range o.Chains {
+		if included == chainName {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *GasImportOptions) policyFor(chainName string) GasMergePolicy {
+	if policy, found := o.ChainPolicies[chainName]; found {
+		return policy
+	}
+	if o.Policy == "" {
+		return NewestWinsGasMergePolicy
+	}
+	return o.Policy
+}
+
+func isValidGasMergePolicy(policy GasMergePolicy) bool {
+	switch policy {
+	case "", NewestWinsGasMergePolicy, MaxPriceGasMergePolicy, OverwriteGasMergePolicy:
+		return true
+	}
+	return false
+}
+
+func policiesOf(chainPolicies map[string]GasMergePolicy) []GasMergePolicy {
+	policies := make([]GasMergePolicy, 0, len(chainPolicies)+1)
+	for _, policy := range chainPolicies {
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// Sorted keys, so that imports apply, and record history, in a stable order.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
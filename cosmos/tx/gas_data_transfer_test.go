@@ -0,0 +1,125 @@
+package tx_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+func TestGasDataTransfer_RoundTripsThroughFile(t *testing.T) {
+	source, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	require.NoError(t, source.SetGasPrice("cosmoshub", "uatom", 0.025))
+	require.NoError(t, source.SetGasFactor("cosmoshub", "uatom", 1.3))
+
+	export, err := tx.ExportGasData(source)
+	require.NoError(t, err)
+	require.False(t, export.GasData.GasPricesUpdatedAt["cosmoshub"]["uatom"].IsZero())
+
+	filename := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, tx.WriteGasDataExport(export, filename))
+	read, err := tx.ReadGasDataExport(filename)
+	require.NoError(t, err)
+
+	destination, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer destination.Close()
+
+	result, err := tx.ImportGasData(destination, read, tx.GasImportOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.ImportedPrices)
+	require.Equal(t, 1, result.ImportedFactors)
+
+	gasFactor, err := destination.GetGasFactor("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 1.3, gasFactor)
+
+	change, err := destination.GetLatestGasChange(tx.GasHistoryQuery{Kind: tx.GasPriceChange})
+	require.NoError(t, err)
+	require.Equal(t, "imported", change.Reason)
+}
+
+func TestGasDataTransfer_MergePolicies(t *testing.T) {
+	source, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	require.NoError(t, source.SetGasPrice("cosmoshub", "uatom", 0.01))
+	require.NoError(t, source.SetGasPrice("osmosis", "uosmo", 0.05))
+	require.NoError(t, source.SetGasPrice("juno", "ujuno", 0.1))
+	export, err := tx.ExportGasData(source)
+	require.NoError(t, err)
+
+	// The destination's values are newer than the export's
+	destination, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	require.NoError(t, destination.SetGasPrice("cosmoshub", "uatom", 0.02))
+	require.NoError(t, destination.SetGasPrice("osmosis", "uosmo", 0.02))
+
+	result, err := tx.ImportGasData(destination, export, tx.GasImportOptions{
+		Policy:        tx.NewestWinsGasMergePolicy,
+		Chains:        []string{"cosmoshub", "osmosis"},
+		ChainPolicies: map[string]tx.GasMergePolicy{"osmosis": tx.MaxPriceGasMergePolicy},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.ImportedPrices)
+	require.Equal(t, 1, result.SkippedPrices)
+
+	// Newest wins keeps the destination's newer price
+	gasPrice, err := destination.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.02, gasPrice)
+
+	// Max price takes the export's larger price
+	gasPrice, err = destination.GetGasPrice("osmosis", "uosmo")
+	require.NoError(t, err)
+	require.Equal(t, 0.05, gasPrice)
+
+	// Unselected chains are not imported
+	hasGasPrice, err := destination.HasGasPrice("juno", "ujuno")
+	require.NoError(t, err)
+	require.False(t, hasGasPrice)
+}
+
+func TestGasDataTransfer_RejectsUnknownPolicies(t *testing.T) {
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	export, err := tx.ExportGasData(provider)
+	require.NoError(t, err)
+
+	_, err = tx.ImportGasData(provider, export, tx.GasImportOptions{Policy: "oldest_wins"})
+	require.Error(t, err)
+}
+
+func TestGasDataTransfer_KeepsExportedTimestamps(t *testing.T) {
+	providerA, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	require.NoError(t, providerA.SetGasPrice("cosmoshub", "uatom", 0.01))
+	require.NoError(t, providerA.SetGasFactor("cosmoshub", "uatom", 1.2))
+	exportA, err := tx.ExportGasData(providerA)
+	require.NoError(t, err)
+
+	providerB, err := tx.NewBoltGasPriceProvider(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer providerB.Close()
+	_, err = tx.ImportGasData(providerB, exportA, tx.GasImportOptions{})
+	require.NoError(t, err)
+
+	// B keeps the times the values were set in A
+	exportB, err := tx.ExportGasData(providerB)
+	require.NoError(t, err)
+	require.Equal(t, exportA.GasData.GasPricesUpdatedAt, exportB.GasData.GasPricesUpdatedAt)
+	require.Equal(t, exportA.GasData.GasFactorsUpdatedAt, exportB.GasData.GasFactorsUpdatedAt)
+
+	// A learns a newer price, which B's copy of the old price does not replace
+	require.NoError(t, providerA.SetGasPrice("cosmoshub", "uatom", 0.02))
+	result, err := tx.ImportGasData(providerA, exportB, tx.GasImportOptions{Policy: tx.NewestWinsGasMergePolicy})
+	require.NoError(t, err)
+	require.Equal(t, 0, result.ImportedPrices)
+	require.Equal(t, 1, result.SkippedPrices)
+
+	gasPrice, err := providerA.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.02, gasPrice)
+}
@@ -0,0 +1,103 @@
+package tx
+
+import (
+	"time"
+)
+
+// Kinds of values that have a history
+const (
+	GasPriceChange  = "gas_price"
+	GasFactorChange = "gas_factor"
+)
+
+// GasChange records a single change to a gas price or gas factor, and why it was made.
+type GasChange struct {
+	Timestamp time.Time `json:"timestamp"`
+
+	ChainName string `json:"chain_name"`
+	Denom     string `json:"denom"`
+
+	// One of GasPriceChange or GasFactorChange
+	Kind string `json:"kind"`
+
+	// The value before the change. Zero if the value was not previously set.
+	OldValue float64 `json:"old_value"`
+	NewValue float64 `json:"new_value"`
+
+	// A human readable reason, like a failing code or a success streak
+	Reason string `json:"reason"`
+}
+
+// GasHistoryQuery filters gas history. Zero values match everything.
+type GasHistoryQuery struct {
+	ChainName string
+	Denom     string
+	Kind      string
+
+	// Only return changes at or after Since, and before Until.
+	Since time.Time
+	Until time.Time
+
+	// Return at most Limit of the most recent matching changes.
+	Limit int
+}
+
+// Matches returns whether a change is selected by the query, ignoring the limit.
+func (q *GasHistoryQuery) Matches(change *GasChange) bool {
+	if q.ChainName != "" && change.ChainName != q.ChainName {
+		return false
+	}
+	if q.Denom != "" && change.Denom != q.Denom {
+		return false
+	}
+	if q.Kind != "" && change.Kind != q.Kind {
+		return false
+	}
+	if !q.Since.IsZero() && change.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !change.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// GasHistoryProvider is a GasPriceProvider which keeps a history of every change to gas prices and factors.
+//
+// Gas managers attach reasons to changes by using the WithReason setters when a provider supports them.
+type GasHistoryProvider interface {
+	GasPriceProvider
+
+	SetGasPriceWithReason(chainName, denom string, gasPrice float64, reason string) error
+	SetGasFactorWithReason(chainName, denom string, gasFactor float64, reason string) error
+
+	// Get changes matching the query, oldest first.
+	GetGasHistory(query GasHistoryQuery) ([]GasChange, error)
+
+	// Get the most recent change matching the query, or nil if there are none.
+	GetLatestGasChange(query GasHistoryQuery) (*GasChange, error)
+
+	// Delete changes made before a time, returning how many were deleted. Current values are not affected.
+	PruneGasHistory(before time.Time) (int, error)
+
+	// Release the underlying store.
+	Close() error
+}
+
+// Set a gas price on a provider, recording the reason if the provider keeps history.
+func setGasPriceWithReason(provider GasPriceProvider, chainName, denom string, gasPrice float64, reason string) error {
+	historyProvider, ok := provider.(GasHistoryProvider)
+	if !ok {
+		return provider.SetGasPrice(chainName, denom, gasPrice)
+	}
+	return historyProvider.SetGasPriceWithReason(chainName, denom, gasPrice, reason)
+}
+
+// Set a gas factor on a provider, recording the reason if the provider keeps history.
+func setGasFactorWithReason(provider GasPriceProvider, chainName, denom string, gasFactor float64, reason string) error {
+	historyProvider, ok := provider.(GasHistoryProvider)
+	if !ok {
+		return provider.SetGasFactor(chainName, denom, gasFactor)
+	}
+	return historyProvider.SetGasFactorWithReason(chainName, denom, gasFactor, reason)
+}
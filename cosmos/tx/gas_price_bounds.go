@@ -0,0 +1,69 @@
+package tx
+
+import (
+	"fmt"
+
+	registry "github.com/tessellated-io/pickaxe/cosmos/chain-registry"
+)
+
+// GasPriceBounds are the lowest and highest gas prices a gas manager will use for a chain. A zero max price is unbounded.
+type GasPriceBounds struct {
+	MinGasPrice float64
+	MaxGasPrice float64
+}
+
+// GasPriceBoundsConfig overrides bounds that would otherwise come from the chain registry.
+type GasPriceBoundsConfig struct {
+	MinGasPrice *float64 `yaml:"min_gas_price,omitempty" comment:"Lowest gas price to use. Defaults to the chain registry's fixed min or low gas price"`
+	MaxGasPrice *float64 `yaml:"max_gas_price,omitempty" comment:"Highest gas price to use. Defaults to the chain registry's high gas price"`
+}
+
+// NewGasPriceBounds creates bounds from a chain registry fee token, applying any overrides. Either argument may be nil.
+//
+// The min price is the fee token's fixed min gas price, or its low gas price if no fixed min is set. The max price is the fee token's high
+// gas price.
+func NewGasPriceBounds(feeToken *registry.FeeToken, overrides *GasPriceBoundsConfig) (*GasPriceBounds, error) {
+	bounds := &GasPriceBounds{}
+
+	if feeToken != nil {
+		bounds.MinGasPrice = feeToken.FixedMinGasPrice
+		if bounds.MinGasPrice == 0 {
+			bounds.MinGasPrice = feeToken.LowGasPrice
+		}
+		bounds.MaxGasPrice = feeToken.HighGasPrice
+	}
+
+	if overrides != nil {
+		if overrides.MinGasPrice != nil {
+			bounds.MinGasPrice = *overrides.MinGasPrice
+		}
+		if overrides.MaxGasPrice != nil {
+			bounds.MaxGasPrice = *overrides.MaxGasPrice
+		}
+	}
+
+	if bounds.MinGasPrice < 0 || bounds.MaxGasPrice < 0 {
+		return nil, fmt.Errorf("invalid gas price bounds: min %f, max %f. Must conform to: bounds >= 0", bounds.MinGasPrice, bounds.MaxGasPrice)
+	}
+	if bounds.MaxGasPrice != 0 && bounds.MinGasPrice > bounds.MaxGasPrice {
+		return nil, fmt.Errorf("invalid gas price bounds: min %f, max %f. Must conform to: min <= max", bounds.MinGasPrice, bounds.MaxGasPrice)
+	}
+
+	return bounds, nil
+}
+
+// Contains returns whether the price is within the bounds.
+func (b *GasPriceBounds) Contains(gasPrice float64) bool {
+	return gasPrice >= b.MinGasPrice && (b.MaxGasPrice == 0 || gasPrice <= b.MaxGasPrice)
+}
+
+// Clamp returns the closest price within the bounds.
+func (b *GasPriceBounds) Clamp(gasPrice float64) float64 {
+	if gasPrice < b.MinGasPrice {
+		return b.MinGasPrice
+	}
+	if b.MaxGasPrice != 0 && gasPrice > b.MaxGasPrice {
+		return b.MaxGasPrice
+	}
+	return gasPrice
+}
@@ -0,0 +1,347 @@
+package tx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+// GasPriceFeedback describes a chain's gas price in a denom, and the streak of outcomes at that price.
+type GasPriceFeedback struct {
+	// The denom the gas price is in
+	Denom string
+
+	// The current gas price
+	Price float64
+
+	// Consecutive successes and failures. At most one of these is non-zero.
+	Successes int
+	Failures  int
+}
+
+// GasPriceStrategy decides how a chain's gas price moves in response to feedback.
+type GasPriceStrategy interface {
+	// A name for the strategy, for logging.
+	Name() string
+
+	// Determine a new gas price for a chain. Returns the new gas price, and whether an adjustment should be made.
+	AdjustPrice(chainName string, feedback GasPriceFeedback) (float64, bool)
+}
+
+// Names of strategies, as they are referred to in config
+const (
+	GeometricGasPriceStrategyName          = "geometric"
+	AIMDGasPriceStrategyName               = "aimd"
+	FixedGasPriceStrategyName              = "fixed"
+	AcceptedPercentileGasPriceStrategyName = "accepted_percentile"
+)
+
+// Default number of consecutive successes before a lower gas price is attempted
+const defaultPriceSuccessThreshold int = 5
+
+// Default ratio the accepted percentile strategy probes below the percentile by
+const defaultAcceptedPercentileDecreaseRatio float64 = 0.9
+
+// Geometric strategy
+//
+// Rough algorithm:
+//   - Given some number of consecutive successes, decrement price by a step size which grows with the success streak.
+//     Formula: price_new = price_old - (step_size * (1 + scale_factor)^(consecutive_successes - success_threshold))
+//   - Given a failure, increase step sizes exponentially.
+//     Formula: price_new = price_old + (step_size * (1 + scale_factor)^(consecutive_failures))
+//
+// Step sizes are bounded to maxStepSize.
+type geometricGasPriceStrategy struct {
+	stepSize         float64
+	maxStepSize      float64
+	scaleFactor      float64
+	successThreshold int
+
+	logger *log.Logger
+}
+
+var _ GasPriceStrategy = (*geometricGasPriceStrategy)(nil)
+
+func NewGeometricGasPriceStrategy(stepSize, maxStepSize, scaleFactor float64, successThreshold int, logger *log.Logger) (GasPriceStrategy, error) {
+	if scaleFactor < 0 || scaleFactor >= 1 {
+		return nil, fmt.Errorf("invalid scale factor: %f. Must conform to: 0 < scale_factor < 1", scaleFactor)
+	}
+	if successThreshold <= 0 {
+		return nil, fmt.Errorf("invalid success threshold: %d. Must conform to: success_threshold > 0", successThreshold)
+	}
+
+	return &geometricGasPriceStrategy{
+		stepSize:         stepSize,
+		maxStepSize:      maxStepSize,
+		scaleFactor:      scaleFactor,
+		successThreshold: successThreshold,
+
+		logger: logger,
+	}, nil
+}
+
+func (s *geometricGasPriceStrategy) Name() string {
+	return GeometricGasPriceStrategyName
+}
+
+func (s *geometricGasPriceStrategy) AdjustPrice(chainName string, feedback GasPriceFeedback) (float64, bool) {
+	logger := s.logger.With("chain_name", chainName, "denom", feedback.Denom, "max_step_size", s.maxStepSize)
+
+	// Do nothing if we don't have a failure or a consecutive success
+	if feedback.Failures == 0 && feedback.Successes < s.successThreshold {
+		return feedback.Price, false
+	}
+
+	// Scale the step according to the streak. Failures scale from the first failure, successes from the threshold.
+	exponent := feedback.Failures
+	if feedback.Failures == 0 {
+		exponent = feedback.Successes - s.successThreshold
+	}
+	scale := math.Pow((1 + s.scaleFactor), float64(exponent))
+	scaledStepSize := s.stepSize * scale
+
+	if scaledStepSize > s.maxStepSize {
+		logger.Warn("bounding step size", "desired_step_size", scaledStepSize)
+		scaledStepSize = s.maxStepSize
+	}
+
+	if feedback.Failures > 0 {
+		return feedback.Price + scaledStepSize, true
+	}
+	return math.Max(feedback.Price-scaledStepSize, 0), true
+}
+
+// AIMD strategy
+//
+// Additive increase, multiplicative decrease:
+//   - Given a failure, increase the price by a constant step.
+//     Formula: price_new = price_old + increase_step
+//   - Given some number of consecutive successes, decrease the price by a constant ratio.
+//     Formula: price_new = price_old * decrease_ratio
+type aimdGasPriceStrategy struct {
+	increaseStep     float64
+	decreaseRatio    float64
+	successThreshold int
+}
+
+var _ GasPriceStrategy = (*aimdGasPriceStrategy)(nil)
+
+func NewAIMDGasPriceStrategy(increaseStep, decreaseRatio float64, successThreshold int) (GasPriceStrategy, error) {
+	if increaseStep <= 0 {
+		return nil, fmt.Errorf("invalid increase step: %f. Must conform to: increase_step > 0", increaseStep)
+	}
+	if decreaseRatio <= 0 || decreaseRatio >= 1 {
+		return nil, fmt.Errorf("invalid decrease ratio: %f. Must conform to: 0 < decrease_ratio < 1", decreaseRatio)
+	}
+	if successThreshold <= 0 {
+		return nil, fmt.Errorf("invalid success threshold: %d. Must conform to: success_threshold > 0", successThreshold)
+	}
+
+	return &aimdGasPriceStrategy{
+		increaseStep:     increaseStep,
+		decreaseRatio:    decreaseRatio,
+		successThreshold: successThreshold,
+	}, nil
+}
+
+func (s *aimdGasPriceStrategy) Name() string {
+	return AIMDGasPriceStrategyName
+}
+
+func (s *aimdGasPriceStrategy) AdjustPrice(chainName string, feedback GasPriceFeedback) (float64, bool) {
+	if feedback.Failures > 0 {
+		return feedback.Price + s.increaseStep, true
+	}
+
+	// Only decrease after a full streak of successes, then start counting again.
+	if feedback.Successes == 0 || feedback.Successes%s.successThreshold != 0 {
+		return feedback.Price, false
+	}
+	return feedback.Price * s.decreaseRatio, true
+}
+
+// Fixed strategy
+//
+// Always uses the same price, regardless of feedback. Gas managers price txs at the fixed price from the first tx, before any feedback.
+type fixedGasPriceStrategy struct {
+	price float64
+}
+
+var _ GasPriceStrategy = (*fixedGasPriceStrategy)(nil)
+
+func NewFixedGasPriceStrategy(price float64) (GasPriceStrategy, error) {
+	if price < 0 {
+		return nil, fmt.Errorf("invalid price: %f. Must conform to: price >= 0", price)
+	}
+
+	return &fixedGasPriceStrategy{
+		price: price,
+	}, nil
+}
+
+func (s *fixedGasPriceStrategy) Name() string {
+	return FixedGasPriceStrategyName
+}
+
+func (s *fixedGasPriceStrategy) AdjustPrice(chainName string, feedback GasPriceFeedback) (float64, bool) {
+	return s.price, feedback.Price != s.price
+}
+
+// Accepted percentile strategy
+//
+// Remembers the prices the most recent successful txs were accepted at, and prices txs at a percentile of them. Only the gas manager's own
+// txs are considered, so this follows what the chain accepted rather than what the market paid. To price from the fees other txs paid in
+// recent blocks, use NewMarketGasManager.
+//   - Given a success, record the price and move to the percentile of recent successful prices.
+//   - Given some number of consecutive successes, probe below the percentile, so that the window learns lower prices when fees fall.
+//     Formula: price_new = percentile * decrease_ratio
+//   - Given a failure, forget recent prices at or below the failing price, and increase the price by a ratio.
+//     Formula: price_new = price_old * (1 + increase_ratio)
+type acceptedPercentileGasPriceStrategy struct {
+	percentile       float64
+	windowSize       int
+	increaseRatio    float64
+	decreaseRatio    float64
+	successThreshold int
+
+	// Recent successful prices, keyed by chain name and denom
+	recentPrices map[gasKey][]float64
+	lock         *sync.Mutex
+}
+
+var _ GasPriceStrategy = (*acceptedPercentileGasPriceStrategy)(nil)
+
+func NewAcceptedPercentileGasPriceStrategy(percentile float64, windowSize int, increaseRatio, decreaseRatio float64, successThreshold int) (GasPriceStrategy, error) {
+	if percentile < 0 || percentile > 100 {
+		return nil, fmt.Errorf("invalid percentile: %f. Must conform to: 0 <= percentile <= 100", percentile)
+	}
+	if windowSize <= 0 {
+		return nil, fmt.Errorf("invalid window size: %d. Must conform to: window_size > 0", windowSize)
+	}
+	if increaseRatio <= 0 {
+		return nil, fmt.Errorf("invalid increase ratio: %f. Must conform to: increase_ratio > 0", increaseRatio)
+	}
+	if decreaseRatio <= 0 || decreaseRatio >= 1 {
+		return nil, fmt.Errorf("invalid decrease ratio: %f. Must conform to: 0 < decrease_ratio < 1", decreaseRatio)
+	}
+	if successThreshold <= 0 {
+		return nil, fmt.Errorf("invalid success threshold: %d. Must conform to: success_threshold > 0", successThreshold)
+	}
+
+	return &acceptedPercentileGasPriceStrategy{
+		percentile:       percentile,
+		windowSize:       windowSize,
+		increaseRatio:    increaseRatio,
+		decreaseRatio:    decreaseRatio,
+		successThreshold: successThreshold,
+
+		recentPrices: make(map[gasKey][]float64),
+		lock:         &sync.Mutex{},
+	}, nil
+}
+
+func (s *acceptedPercentileGasPriceStrategy) Name() string {
+	return AcceptedPercentileGasPriceStrategyName
+}
+
+func (s *acceptedPercentileGasPriceStrategy) AdjustPrice(chainName string, feedback GasPriceFeedback) (float64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := gasKey{chainName: chainName, denom: feedback.Denom}
+	recentPrices := s.recentPrices[key]
+
+	if feedback.Failures > 0 {
+		// Prices at or below the failing price are no longer good evidence.
+		remaining := []float64{}
+		for _, price := range recentPrices {
+			if price > feedback.Price {
+				remaining = append(remaining, price)
+			}
+		}
+		s.recentPrices[key] = remaining
+
+		return feedback.Price * (1 + s.increaseRatio), true
+	}
+
+	// Record the success, dropping the oldest price if the window is full
+	recentPrices = append(recentPrices, feedback.Price)
+	if len(recentPrices) > s.windowSize {
+		recentPrices = recentPrices[len(recentPrices)-s.windowSize:]
+	}
+	s.recentPrices[key] = recentPrices
+
+	newPrice := percentileOf(recentPrices, s.percentile)
+
+	// Probe below the percentile after a full streak of successes, then start counting again. A successful probe joins the window.
+	if feedback.Successes > 0 && feedback.Successes%s.successThreshold == 0 {
+		newPrice *= s.decreaseRatio
+	}
+	return newPrice, newPrice != feedback.Price
+}
+
+// Get the value at a percentile of the values, using the nearest rank.
+func percentileOf(values []float64, percentile float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(percentile/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// GasPriceStrategyConfig selects and parameterizes a gas price strategy.
+type GasPriceStrategyConfig struct {
+	Strategy string `yaml:"strategy" comment:"One of: geometric, aimd, fixed, accepted_percentile"`
+
+	// Geometric
+	StepSize    float64 `yaml:"step_size,omitempty" comment:"geometric: base amount to step the gas price by"`
+	MaxStepSize float64 `yaml:"max_step_size,omitempty" comment:"geometric: largest amount to step the gas price by"`
+	ScaleFactor float64 `yaml:"scale_factor,omitempty" comment:"geometric: growth of the step size for each consecutive outcome"`
+
+	// AIMD
+	IncreaseStep  float64 `yaml:"increase_step,omitempty" comment:"aimd: amount to increase the gas price by on failure"`
+	DecreaseRatio float64 `yaml:"decrease_ratio,omitempty" comment:"aimd, accepted_percentile: ratio to multiply the gas price by after consecutive successes"`
+
+	// Geometric, AIMD and accepted percentile
+	SuccessThreshold int `yaml:"success_threshold,omitempty" comment:"geometric, aimd, accepted_percentile: consecutive successes before lowering the gas price"`
+
+	// Fixed
+	Price float64 `yaml:"price,omitempty" comment:"fixed: the gas price to use"`
+
+	// Accepted percentile
+	Percentile    float64 `yaml:"percentile,omitempty" comment:"accepted_percentile: percentile of gas prices recent successful txs were accepted at"`
+	WindowSize    int     `yaml:"window_size,omitempty" comment:"accepted_percentile: number of recent successful gas prices to remember"`
+	IncreaseRatio float64 `yaml:"increase_ratio,omitempty" comment:"accepted_percentile: ratio to increase the gas price by on failure"`
+}
+
+// NewGasPriceStrategyFromConfig creates the gas price strategy described by the config.
+func NewGasPriceStrategyFromConfig(config *GasPriceStrategyConfig, logger *log.Logger) (GasPriceStrategy, error) {
+	successThreshold := config.SuccessThreshold
+	if successThreshold == 0 {
+		successThreshold = defaultPriceSuccessThreshold
+	}
+
+	switch config.Strategy {
+	case GeometricGasPriceStrategyName:
+		return NewGeometricGasPriceStrategy(config.StepSize, config.MaxStepSize, config.ScaleFactor, successThreshold, logger)
+	case AIMDGasPriceStrategyName:
+		return NewAIMDGasPriceStrategy(config.IncreaseStep, config.DecreaseRatio, successThreshold)
+	case FixedGasPriceStrategyName:
+		return NewFixedGasPriceStrategy(config.Price)
+	case AcceptedPercentileGasPriceStrategyName:
+		decreaseRatio := config.DecreaseRatio
+		if decreaseRatio == 0 {
+			decreaseRatio = defaultAcceptedPercentileDecreaseRatio
+		}
+		return NewAcceptedPercentileGasPriceStrategy(config.Percentile, config.WindowSize, config.IncreaseRatio, decreaseRatio, successThreshold)
+	}
+
+	return nil, fmt.Errorf("unknown gas price strategy: %s", config.Strategy)
+}
@@ -0,0 +1,124 @@
+package tx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+func TestGasPriceStrategy_Geometric(t *testing.T) {
+	strategy, err := tx.NewGeometricGasPriceStrategy(0.01, 0.05, 0.5, 5, log.Default())
+	require.NoError(t, err)
+
+	// Not enough successes
+	_, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Successes: 4})
+	require.False(t, shouldAdjust)
+
+	// Threshold reached, step down by one step
+	newPrice, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Successes: 5})
+	require.True(t, shouldAdjust)
+	require.InDelta(t, 0.99, newPrice, 1e-9)
+
+	// Failures step up geometrically, and are bounded
+	newPrice, _ = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Failures: 1})
+	require.InDelta(t, 1.015, newPrice, 1e-9)
+
+	newPrice, _ = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Failures: 10})
+	require.InDelta(t, 1.05, newPrice, 1e-9)
+}
+
+func TestGasPriceStrategy_AIMD(t *testing.T) {
+	strategy, err := tx.NewAIMDGasPriceStrategy(0.1, 0.5, 2)
+	require.NoError(t, err)
+
+	newPrice, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Failures: 3})
+	require.True(t, shouldAdjust)
+	require.InDelta(t, 1.1, newPrice, 1e-9)
+
+	_, shouldAdjust = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Successes: 1})
+	require.False(t, shouldAdjust)
+
+	newPrice, shouldAdjust = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Successes: 2})
+	require.True(t, shouldAdjust)
+	require.InDelta(t, 0.5, newPrice, 1e-9)
+}
+
+func TestGasPriceStrategy_AcceptedPercentile(t *testing.T) {
+	strategy, err := tx.NewAcceptedPercentileGasPriceStrategy(50, 3, 0.2, 0.5, 5)
+	require.NoError(t, err)
+
+	for _, price := range []float64{3, 2, 2} {
+		strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: price, Successes: 1})
+	}
+
+	// Window is [2, 2, 1] after this success, so the median is 2.
+	newPrice, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Successes: 1})
+	require.True(t, shouldAdjust)
+	require.Equal(t, 2.0, newPrice)
+
+	// A failure at 2 drops prices at or below it, and increases the price.
+	newPrice, shouldAdjust = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 2, Failures: 1})
+	require.True(t, shouldAdjust)
+	require.InDelta(t, 2.4, newPrice, 1e-9)
+
+	// Other chains are unaffected.
+	newPrice, _ = strategy.AdjustPrice("other", tx.GasPriceFeedback{Price: 5, Successes: 1})
+	require.Equal(t, 5.0, newPrice)
+}
+
+func TestGasPriceStrategy_AcceptedPercentileComesBackDown(t *testing.T) {
+	strategy, err := tx.NewAcceptedPercentileGasPriceStrategy(50, 3, 0.2, 0.5, 2)
+	require.NoError(t, err)
+
+	// A spike raises the price
+	price, _ := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Failures: 1})
+	price, _ = strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: price, Failures: 2})
+	require.InDelta(t, 1.44, price, 1e-9)
+
+	// Once fees fall, successful probes pull the percentile down below where the spike left it
+	successes := 0
+	for i := 0; i < 12; i++ {
+		successes++
+		newPrice, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: price, Successes: successes})
+		if shouldAdjust {
+			price = newPrice
+		}
+	}
+	require.Less(t, price, 1.0)
+}
+
+func TestGasPriceStrategy_FromConfig(t *testing.T) {
+	strategy, err := tx.NewGasPriceStrategyFromConfig(&tx.GasPriceStrategyConfig{Strategy: "fixed", Price: 0.025}, log.Default())
+	require.NoError(t, err)
+	require.Equal(t, tx.FixedGasPriceStrategyName, strategy.Name())
+
+	newPrice, shouldAdjust := strategy.AdjustPrice("chain", tx.GasPriceFeedback{Price: 1, Failures: 1})
+	require.True(t, shouldAdjust)
+	require.Equal(t, 0.025, newPrice)
+
+	_, err = tx.NewGasPriceStrategyFromConfig(&tx.GasPriceStrategyConfig{Strategy: "unknown"}, log.Default())
+	require.Error(t, err)
+}
+
+func TestGasPriceStrategy_FixedPricesFirstTx(t *testing.T) {
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	strategy, err := tx.NewFixedGasPriceStrategy(0.025)
+	require.NoError(t, err)
+	gasManager, err := tx.NewGeometricGasManager(0.01, 0.1, 0.2, provider, log.Default(), tx.WithGasPriceStrategy("chain", strategy))
+	require.NoError(t, err)
+
+	// The fixed price is used before any feedback, even over an initialized price
+	require.NoError(t, gasManager.InitializePrice("chain", "ufoo", 0.01))
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+
+	// Other chains are priced as usual
+	require.NoError(t, gasManager.InitializePrice("other-chain", "ufoo", 0.01))
+	gasPrice, err = gasManager.GetGasPrice("other-chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 0.01, gasPrice)
+}
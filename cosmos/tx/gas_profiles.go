@@ -0,0 +1,273 @@
+package tx
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// Gas profiles
+//
+// Different kinds of txs use very different amounts of gas compared to their simulations. For instance, a MsgExec wrapping delegations
+// behaves differently to an IBC transfer or a wasm execute. Gas profiles learn a gas factor for each chain and set of message types, in the
+// same way as the learned gas factor manager:
+//
+//	Formula: gas_factor = percentile(recent_ratios_of_used_to_simulated_gas) + margin
+//
+// Pass profiles to a TxProvider with WithGasProfiles to build txs with profile gas factors, and feed them with gas usage by wrapping the
+// broadcaster's gas manager with NewGasProfilingGasManager.
+
+// GasProfile describes what has been learned about gas usage for a chain and set of message types.
+type GasProfile struct {
+	// The profile key, and the message type URLs it is made of
+	Key      string
+	TypeURLs []string
+
+	// Statistics for ratios of used to simulated gas
+	Samples   int
+	MinRatio  float64
+	MeanRatio float64
+	MaxRatio  float64
+
+	// The learned gas factor. Zero if too few samples have been observed.
+	GasFactor float64
+}
+
+// GasProfiles tracks gas usage for chains, keyed by chain name and profile key.
+type GasProfiles interface {
+	// Get the gas factor for a profile, or fallback if too few samples have been observed.
+	GetGasFactor(chainName, profileKey string, fallback float64) float64
+
+	// Record a tx's gas usage. gasFactor is the factor the tx was built with.
+	RecordGasUsage(chainName, profileKey string, gasFactor float64, gasWanted, gasUsed int64)
+
+	// Get statistics for a profile. Returns false if nothing has been recorded.
+	GetGasProfile(chainName, profileKey string) (*GasProfile, bool)
+
+	// Get statistics for all of a chain's profiles, sorted by key.
+	GetGasProfiles(chainName string) []*GasProfile
+}
+
+// Separates message type URLs in profile keys
+const gasProfileKeySeparator = ","
+
+// GasProfileKey returns the profile key for a set of message type URLs. Order and duplicates do not matter.
+func GasProfileKey(typeURLs []string) string {
+	unique := make(map[string]bool)
+	for _, typeURL := range typeURLs {
+		unique[typeURL] = true
+	}
+
+	sorted := make([]string, 0, len(unique))
+	for typeURL := range unique {
+		sorted = append(sorted, typeURL)
+	}
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, gasProfileKeySeparator)
+}
+
+// GasProfileKeyForMsgs returns the profile key for a tx containing msgs.
+func GasProfileKeyForMsgs(msgs []sdk.Msg) string {
+	typeURLs := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		typeURLs = append(typeURLs, sdk.MsgTypeURL(msg))
+	}
+	return GasProfileKey(typeURLs)
+}
+
+// GasProfileKeyForTx returns the profile key for an encoded tx, or false if the tx has no body.
+func GasProfileKeyForTx(tx *txtypes.Tx) (string, bool) {
+	if tx == nil || tx.Body == nil {
+		return "", false
+	}
+
+	typeURLs := make([]string, 0, len(tx.Body.Messages))
+	for _, msg := range tx.Body.Messages {
+		typeURLs = append(typeURLs, msg.TypeUrl)
+	}
+	return GasProfileKey(typeURLs), true
+}
+
+// In memory gas profiles
+type gasProfiles struct {
+	// Recent ratios of used to simulated gas, keyed by chain name and profile key
+	ratios *gasRatioWindow
+
+	logger *log.Logger
+}
+
+var _ GasProfiles = (*gasProfiles)(nil)
+
+// NewGasProfiles creates gas profiles that derive gas factors from the last windowSize ratios of used to simulated gas. Learned factors
+// are used once minSamples ratios are known.
+func NewGasProfiles(percentile, margin float64, windowSize, minSamples int, logger *log.Logger) (GasProfiles, error) {
+	ratios, err := newGasRatioWindow(percentile, margin, windowSize, minSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gasProfiles{
+		ratios: ratios,
+		logger: logger.ApplyPrefix("⛽️"),
+	}, nil
+}
+
+func (gp *gasProfiles) GetGasFactor(chainName, profileKey string, fallback float64) float64 {
+	gasFactor, learned := gp.ratios.gasFactor(gasKey{chainName: chainName, denom: profileKey})
+	if !learned {
+		return fallback
+	}
+	return gasFactor
+}
+
+func (gp *gasProfiles) RecordGasUsage(chainName, profileKey string, gasFactor float64, gasWanted, gasUsed int64) {
+	logger := gp.logger.With("chain_name", chainName, "profile", profileKey, "gas_factor", gasFactor, "gas_wanted", gasWanted, "gas_used", gasUsed)
+
+	ratio, samples, recorded := gp.ratios.record(gasKey{chainName: chainName, denom: profileKey}, gasFactor, gasWanted, gasUsed)
+	if !recorded {
+		logger.Debug("tx did not report gas usage, not updating gas profile")
+		return
+	}
+
+	logger.Debug("recorded ratio of used to simulated gas", "ratio", ratio, "samples", samples)
+}
+
+func (gp *gasProfiles) GetGasProfile(chainName, profileKey string) (*GasProfile, bool) {
+	ratios, found := gp.ratios.ratiosFor(gasKey{chainName: chainName, denom: profileKey})
+	if !found {
+		return nil, false
+	}
+	return gp.profile(profileKey, ratios), true
+}
+
+func (gp *gasProfiles) GetGasProfiles(chainName string) []*GasProfile {
+	profiles := []*GasProfile{}
+	for key, ratios := range gp.ratios.ratiosForChain(chainName) {
+		profiles = append(profiles, gp.profile(key.denom, ratios))
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Key < profiles[j].Key
+	})
+	return profiles
+}
+
+// Helpers
+
+func (gp *gasProfiles) profile(profileKey string, ratios []float64) *GasProfile {
+	profile := &GasProfile{
+		Key:     profileKey,
+		Samples: len(ratios),
+	}
+	if profileKey != "" {
+		profile.TypeURLs = strings.Split(profileKey, gasProfileKeySeparator)
+	}
+
+	sum := 0.0
+	for i, ratio := range ratios {
+		if i == 0 || ratio < profile.MinRatio {
+			profile.MinRatio = ratio
+		}
+		if i == 0 || ratio > profile.MaxRatio {
+			profile.MaxRatio = ratio
+		}
+		sum += ratio
+	}
+	if len(ratios) > 0 {
+		profile.MeanRatio = sum / float64(len(ratios))
+	}
+	profile.GasFactor, _ = gp.ratios.gasFactorFor(ratios)
+
+	return profile
+}
+
+// Gas manager which records gas usage into gas profiles.
+//
+// Feedback is always passed through to the wrapped gas manager. Successful, and out of gas, included txs are additionally recorded against
+// the profile for the messages in the tx, using the gas factor the broadcaster built the tx with.
+type gasProfilingGasManager struct {
+	profiles GasProfiles
+	wrapped  GasManager
+}
+
+var (
+	_ adjustmentReportingGasManager = (*gasProfilingGasManager)(nil)
+	_ gasUsageLearningGasManager    = (*gasProfilingGasManager)(nil)
+)
+
+// NewGasProfilingGasManager creates a gas manager that feeds profiles with gas usage from included txs.
+func NewGasProfilingGasManager(profiles GasProfiles, wrapped GasManager) (GasManager, error) {
+	return &gasProfilingGasManager{
+		profiles: profiles,
+		wrapped:  wrapped,
+	}, nil
+}
+
+func (g *gasProfilingGasManager) InitializePrice(chainName, denom string, gasPrice float64) error {
+	return g.wrapped.InitializePrice(chainName, denom, gasPrice)
+}
+
+func (g *gasProfilingGasManager) GetGasPrice(chainName, denom string) (float64, error) {
+	return g.wrapped.GetGasPrice(chainName, denom)
+}
+
+func (g *gasProfilingGasManager) GetGasFactor(chainName, denom string) (float64, error) {
+	return g.wrapped.GetGasFactor(chainName, denom)
+}
+
+func (g *gasProfilingGasManager) withAdjustments(adjustments *gasAdjustments) (GasManager, bool) {
+	wrapped, ok := withGasAdjustments(g.wrapped, adjustments)
+	if !ok {
+		return g, false
+	}
+
+	return &gasProfilingGasManager{
+		profiles: g.profiles,
+		wrapped:  wrapped,
+	}, true
+}
+
+func (g *gasProfilingGasManager) ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error {
+	return g.wrapped.ManageFailingBroadcastResult(chainName, denom, broadcastResult)
+}
+
+func (g *gasProfilingGasManager) ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error {
+	profileKey, hasProfileKey := GasProfileKeyForTx(txStatus.Tx)
+	if hasProfileKey {
+		// Without the factor the tx was built with, assume it was the profile's current factor, if it has one, and the chain's otherwise
+		fallback, err := g.wrapped.GetGasFactor(chainName, denom)
+		if err != nil {
+			return err
+		}
+		g.recordGasUsage(chainName, profileKey, txStatus, g.profiles.GetGasFactor(chainName, profileKey, fallback))
+	}
+
+	return g.wrapped.ManageIncludedTransactionStatus(chainName, denom, txStatus)
+}
+
+func (g *gasProfilingGasManager) manageIncludedTransactionGasFactor(chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64) error {
+	profileKey, hasProfileKey := GasProfileKeyForTx(txStatus.Tx)
+	if hasProfileKey {
+		g.recordGasUsage(chainName, profileKey, txStatus, gasFactor)
+	}
+
+	return manageIncludedTransactionStatus(g.wrapped, chainName, denom, txStatus, gasFactor, true)
+}
+
+func (g *gasProfilingGasManager) ManageInclusionFailure(chainName, denom string) error {
+	return g.wrapped.ManageInclusionFailure(chainName, denom)
+}
+
+// Record the gas usage of a tx built with gasFactor against its profile, if the tx tells us about gas usage.
+func (g *gasProfilingGasManager) recordGasUsage(chainName, profileKey string, txStatus *txtypes.GetTxResponse, gasFactor float64) {
+	if !isGasUsageSample(chainName, txStatus) {
+		return
+	}
+
+	txResponse := txStatus.TxResponse
+	g.profiles.RecordGasUsage(chainName, profileKey, gasFactor, txResponse.GasWanted, txResponse.GasUsed)
+}
@@ -0,0 +1,172 @@
+package tx_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+const (
+	msgSendTypeURL = "/cosmos.bank.v1beta1.MsgSend"
+	msgExecTypeURL = "/cosmos.authz.v1beta1.MsgExec"
+)
+
+func TestGasProfileKey_IgnoresOrderAndDuplicates(t *testing.T) {
+	key := tx.GasProfileKey([]string{msgSendTypeURL, msgExecTypeURL, msgSendTypeURL})
+	require.Equal(t, msgExecTypeURL+","+msgSendTypeURL, key)
+
+	require.Equal(t, msgSendTypeURL, tx.GasProfileKeyForMsgs([]sdk.Msg{&banktypes.MsgSend{}, &banktypes.MsgSend{}}))
+}
+
+func TestGasProfilingGasManager_LearnsPerProfile(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+
+	profiles, err := tx.NewGasProfiles(100, 0.1, 10, 2, log.Default())
+	require.NoError(t, err)
+	gasManager, err := tx.NewGasProfilingGasManager(profiles, wrapped)
+	require.NoError(t, err)
+
+	// MsgExec txs use twice their simulated gas. Gas wanted is simulated gas scaled by the default factor of 1.1.
+	for i := 0; i < 2; i++ {
+		status := &txtypes.GetTxResponse{
+			Tx: &txtypes.Tx{
+				Body: &txtypes.TxBody{Messages: []*codectypes.Any{{TypeUrl: msgExecTypeURL}}},
+			},
+			TxResponse: &sdk.TxResponse{Code: 0, GasUsed: 200_000, GasWanted: 110_000},
+		}
+		require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain", "ufoo", status))
+	}
+
+	profile, found := profiles.GetGasProfile("chain", msgExecTypeURL)
+	require.True(t, found)
+	require.Equal(t, 2, profile.Samples)
+	require.Equal(t, []string{msgExecTypeURL}, profile.TypeURLs)
+	require.InDelta(t, 2.0, profile.MeanRatio, 0.0001)
+	require.InDelta(t, 2.1, profile.GasFactor, 0.0001)
+
+	require.InDelta(t, 2.1, profiles.GetGasFactor("chain", msgExecTypeURL, 1.1), 0.0001)
+
+	// Unknown profiles use the fallback
+	require.Equal(t, 1.1, profiles.GetGasFactor("chain", msgSendTypeURL, 1.1))
+	require.Len(t, profiles.GetGasProfiles("chain"), 1)
+}
+
+// profiledRpcClient reports that txs contain a MsgSend and used 1.8 times the gas they wanted.
+type profiledRpcClient struct {
+	sequenceRpcClient
+}
+
+func (c *profiledRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	return &txtypes.GetTxResponse{
+		Tx:         &txtypes.Tx{Body: &txtypes.TxBody{Messages: []*codectypes.Any{{TypeUrl: msgSendTypeURL}}}},
+		TxResponse: &sdk.TxResponse{TxHash: txHash, GasWanted: 100, GasUsed: 180},
+	}, nil
+}
+
+// profileSimulationManager simulates every tx as using 100 gas.
+type profileSimulationManager struct {
+	tx.SimulationManager
+}
+
+func (m *profileSimulationManager) SimulateTx(ctx context.Context, simulatedTx authsigning.Tx, gasFactor float64) (*tx.SimulationResult, error) {
+	return &tx.SimulationResult{GasRecommendation: int64(math.Ceil(100 * gasFactor))}, nil
+}
+
+func TestTxProvider_UsesGasProfiles(t *testing.T) {
+	rpcClient := &sequenceRpcClient{}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	signingMetadata, err := signingMetadataProvider.SigningMetadataForAccount(context.Background(), "cosmos1address")
+	require.NoError(t, err)
+
+	// MsgSend txs use twice their simulated gas
+	profiles, err := tx.NewGasProfiles(100, 0.1, 10, 1, log.Default())
+	require.NoError(t, err)
+	profiles.RecordGasUsage("chain", msgSendTypeURL, 1.0, 100, 200)
+
+	privKey := secp256k1.GenPrivKey()
+	signer := &crypto.KeyPair{Public: privKey.PubKey(), Private: privKey}
+	txConfig := authtx.NewTxConfig(codec.NewProtoCodec(codectypes.NewInterfaceRegistry()), authtx.DefaultSignModes)
+	txProvider, err := tx.NewTxProvider(signer, "chain-1", "ufoo", "", log.Default(), &profileSimulationManager{}, txConfig, tx.WithGasProfiles("chain", profiles))
+	require.NoError(t, err)
+
+	// Txs are signed and simulated with the profile's factor
+	_, gasWanted, err := txProvider.ProvideTx(context.Background(), 0.01, 1.1, []sdk.Msg{&banktypes.MsgSend{}}, signingMetadata)
+	require.NoError(t, err)
+	require.Equal(t, int64(210), gasWanted)
+
+	_, gasWanted, err = txProvider.(tx.TxSimulator).SimulateMsgs(context.Background(), 0.01, 1.1, []sdk.Msg{&banktypes.MsgSend{}}, signingMetadata)
+	require.NoError(t, err)
+	require.Equal(t, int64(210), gasWanted)
+
+	// Messages without a profile use the given factor
+	_, gasWanted, err = txProvider.ProvideTx(context.Background(), 0.01, 1.5, []sdk.Msg{&banktypes.MsgMultiSend{}}, signingMetadata)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), gasWanted)
+}
+
+// signedHooks calls signed when a tx is signed.
+type signedHooks struct {
+	tx.NoopBroadcastHooks
+
+	signed func()
+}
+
+func (h *signedHooks) TxSigned(ctx context.Context, event tx.TxEvent) {
+	h.signed()
+}
+
+func TestBroadcaster_FeedsGasProfilesWithSigningGasFactor(t *testing.T) {
+	rpcClient := &profiledRpcClient{}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	profiles, err := tx.NewGasProfiles(100, 0.1, 10, 1, log.Default())
+	require.NoError(t, err)
+	wrapped := newBacktestGasManager(t, 0.01)
+	chainGasFactor, err := wrapped.GetGasFactor("chain", "ufoo")
+	require.NoError(t, err)
+	gasManager, err := tx.NewGasProfilingGasManager(profiles, wrapped)
+	require.NoError(t, err)
+
+	// The profile's factor changes while the tx is in flight
+	hooks := &signedHooks{signed: func() {
+		profiles.RecordGasUsage("chain", msgSendTypeURL, 1.0, 100, 300)
+	}}
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithHooks(hooks),
+	)
+	require.NoError(t, err)
+
+	_, err = broadcaster.SignAndBroadcast(context.Background(), []sdk.Msg{&banktypes.MsgSend{}})
+	require.NoError(t, err)
+
+	// The tx's gas usage was recorded against the chain's factor it was built with, rather than the profile's factor when it landed
+	profile, found := profiles.GetGasProfile("chain", msgSendTypeURL)
+	require.True(t, found)
+	require.Equal(t, 2, profile.Samples)
+	require.InDelta(t, 1.8*chainGasFactor, profile.MinRatio, 0.0001)
+	require.InDelta(t, 3.0, profile.MaxRatio, 0.0001)
+}
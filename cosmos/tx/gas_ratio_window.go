@@ -0,0 +1,128 @@
+package tx
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// gasRatioWindow keeps recent ratios of used to simulated gas, and derives gas factors from them.
+//
+// Gas wanted is the simulated gas multiplied by the gas factor, so the ratio of used gas to simulated gas can be recovered:
+//
+//	Formula: ratio = gas_used * gas_factor / gas_wanted
+//
+// The gas factor is then a high percentile of recent ratios, plus a margin:
+//
+//	Formula: gas_factor = percentile(recent_ratios) + margin
+//
+// Ratios are keyed by chain name and a second key, which is a denom or a gas profile key depending on the caller.
+type gasRatioWindow struct {
+	// Parameters
+	percentile float64
+	margin     float64
+	windowSize int
+	minSamples int
+
+	// Recent ratios, oldest first
+	ratios map[gasKey][]float64
+	lock   *sync.Mutex
+}
+
+// Create a window of the last windowSize ratios. Gas factors are derived once minSamples ratios are known.
+func newGasRatioWindow(percentile, margin float64, windowSize, minSamples int) (*gasRatioWindow, error) {
+	if percentile < 0 || percentile > 100 {
+		return nil, fmt.Errorf("invalid percentile: %f. Must conform to: 0 <= percentile <= 100", percentile)
+	}
+	if margin < 0 {
+		return nil, fmt.Errorf("invalid margin: %f. Must conform to: margin >= 0", margin)
+	}
+	if windowSize <= 0 {
+		return nil, fmt.Errorf("invalid window size: %d. Must conform to: window_size > 0", windowSize)
+	}
+	if minSamples <= 0 || minSamples > windowSize {
+		return nil, fmt.Errorf("invalid min samples: %d. Must conform to: 0 < min_samples <= window_size", minSamples)
+	}
+
+	return &gasRatioWindow{
+		percentile: percentile,
+		margin:     margin,
+		windowSize: windowSize,
+		minSamples: minSamples,
+
+		ratios: make(map[gasKey][]float64),
+		lock:   &sync.Mutex{},
+	}, nil
+}
+
+// Get the gas factor for a key. Returns false if too few ratios have been recorded.
+func (w *gasRatioWindow) gasFactor(key gasKey) (float64, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.gasFactorFor(w.ratios[key])
+}
+
+// Record the ratio for a tx built with gasFactor, dropping the oldest ratio if the window is full. Returns the ratio and the number of
+// samples, or false if the tx did not report gas usage.
+func (w *gasRatioWindow) record(key gasKey, gasFactor float64, gasWanted, gasUsed int64) (float64, int, bool) {
+	if gasUsed <= 0 || gasWanted <= 0 || gasFactor <= 0 {
+		return 0, 0, false
+	}
+	ratio := float64(gasUsed) * gasFactor / float64(gasWanted)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	ratios := append(w.ratios[key], ratio)
+	if len(ratios) > w.windowSize {
+		ratios = ratios[len(ratios)-w.windowSize:]
+	}
+	w.ratios[key] = ratios
+
+	return ratio, len(ratios), true
+}
+
+// Get a copy of the ratios for every key on a chain.
+func (w *gasRatioWindow) ratiosForChain(chainName string) map[gasKey][]float64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	result := make(map[gasKey][]float64)
+	for key, ratios := range w.ratios {
+		if key.chainName == chainName {
+			result[key] = append([]float64{}, ratios...)
+		}
+	}
+	return result
+}
+
+// Get a copy of the ratios for a key. Returns false if nothing has been recorded.
+func (w *gasRatioWindow) ratiosFor(key gasKey) ([]float64, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	ratios, found := w.ratios[key]
+	return append([]float64{}, ratios...), found
+}
+
+// Get the gas factor for a set of ratios. Returns false if there are too few.
+func (w *gasRatioWindow) gasFactorFor(ratios []float64) (float64, bool) {
+	if len(ratios) < w.minSamples {
+		return 0, false
+	}
+	return percentileOf(ratios, w.percentile) + w.margin, true
+}
+
+// Whether an included tx tells us about gas usage. Successful txs, and txs which ran out of gas, both do. Out of gas txs stop executing, so
+// their ratio is a lower bound, which still pushes the learned factor in the right direction.
+func isGasUsageSample(chainName string, txStatus *txtypes.GetTxResponse) bool {
+	if txStatus == nil || txStatus.TxResponse == nil {
+		return false
+	}
+	return IsSuccessTxStatus(txStatus) || errors.Is(abci.ForChain(chainName).FromTxResponse(txStatus.TxResponse), abci.ErrOutOfGas)
+}
@@ -0,0 +1,102 @@
+package tx_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+func TestFileGasPriceProvider_MigratesLegacyFormat(t *testing.T) {
+	dataDirectory := t.TempDir()
+	legacyData := `{"gas_factors": {"cosmoshub": 1.2}, "gas_prices": {"cosmoshub": 0.025}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dataDirectory, "gas_prices.json"), []byte(legacyData), 0o600))
+
+	provider, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	// The first denom requested adopts the legacy values
+	gasPrice, err := provider.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+
+	gasFactor, err := provider.GetGasFactor("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 1.2, gasFactor)
+
+	// Other denoms do not
+	hasGasPrice, err := provider.HasGasPrice("cosmoshub", "ibc/ABC")
+	require.NoError(t, err)
+	require.False(t, hasGasPrice)
+
+	// The migration is persisted
+	reloaded, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	gasPrice, err = reloaded.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+}
+
+func TestFileGasPriceProvider_MergesWritesFromOtherProviders(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	providerA, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	providerB, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	require.NoError(t, providerA.SetGasPrice("cosmoshub", "uatom", 0.025))
+	require.NoError(t, providerB.SetGasPrice("osmosis", "uosmo", 0.01))
+
+	// Neither write clobbers the other
+	reloaded, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	gasPrice, err := reloaded.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+
+	gasPrice, err = reloaded.GetGasPrice("osmosis", "uosmo")
+	require.NoError(t, err)
+	require.Equal(t, 0.01, gasPrice)
+}
+
+func TestFileGasPriceProvider_RestoresFromBackup(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	provider, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	require.NoError(t, provider.SetGasPrice("cosmoshub", "uatom", 0.025))
+	require.NoError(t, provider.SetGasPrice("cosmoshub", "uatom", 0.03))
+
+	// Corrupt the main file
+	require.NoError(t, os.WriteFile(filepath.Join(dataDirectory, "gas_prices.json"), []byte(`{"gas_pri`), 0o600))
+
+	reloaded, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	gasPrice, err := reloaded.GetGasPrice("cosmoshub", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+}
+
+func TestFileGasPriceProvider_RejectsNewerVersions(t *testing.T) {
+	dataDirectory := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDirectory, "gas_prices.json"), []byte(`{"version": 99}`), 0o600))
+
+	_, err := tx.NewFileGasPriceProvider(log.Default(), dataDirectory)
+	require.Error(t, err)
+}
+
+func TestIsGasPriceError_UsesChainRegistry(t *testing.T) {
+	abci.RegisterChain("gaspricechain").Register("feemodule", 3, abci.ErrInsufficientFee)
+
+	require.True(t, tx.IsGasPriceError("gaspricechain", "feemodule", 3))
+	require.True(t, tx.IsGasRelatedError("gaspricechain", "sdk", 11))
+	require.False(t, tx.IsGasPriceError("cosmoshub", "feemodule", 3))
+}
@@ -0,0 +1,166 @@
+package tx
+
+import (
+	"fmt"
+
+	"github.com/tessellated-io/pickaxe/log"
+
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// Gas manager which learns gas factors from the gas txs actually use.
+//
+// Every included tx reports the gas it used against the gas it wanted, from which a ratio of used to simulated gas is recovered. Gas
+// factors are a high percentile of recent ratios, plus a margin. See gasRatioWindow for the formulas.
+//
+// Ratios are recovered with the gas factor the broadcaster built each tx with. Feedback from callers which do not know it falls back to the
+// factor currently provided for the chain, which may differ from the one the tx was built with if the factor moved while it was in flight.
+//
+// Until enough ratios have been observed, or if an operator has pinned the factor, gas factors come from the wrapped gas manager. Prices and
+// feedback are always delegated to the wrapped gas manager.
+type learnedGasFactorManager struct {
+	// Recent ratios of used to simulated gas, keyed by chain name and denom
+	ratios *gasRatioWindow
+
+	// Values which operators have pinned. May be nil.
+	pins *GasPins
+
+	// Where feedback records the changes it makes. Nil unless the manager was copied with withAdjustments.
+	adjustments *gasAdjustments
+
+	// Services
+	logger  *log.Logger
+	wrapped GasManager
+}
+
+var (
+	_ adjustmentReportingGasManager = (*learnedGasFactorManager)(nil)
+	_ gasUsageLearningGasManager    = (*learnedGasFactorManager)(nil)
+)
+
+// NewLearnedGasFactorManager creates a gas manager that derives gas factors from the last windowSize ratios of used to simulated gas.
+// Learned factors are used once minSamples ratios are known, unless the factor is pinned in pins. pins may be nil.
+func NewLearnedGasFactorManager(
+	percentile float64,
+	margin float64,
+	windowSize int,
+	minSamples int,
+	wrapped GasManager,
+	pins *GasPins,
+	logger *log.Logger,
+) (GasManager, error) {
+	ratios, err := newGasRatioWindow(percentile, margin, windowSize, minSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	gasManager := &learnedGasFactorManager{
+		ratios: ratios,
+		pins:   pins,
+
+		logger:  logger.ApplyPrefix("⛽️"),
+		wrapped: wrapped,
+	}
+
+	return gasManager, nil
+}
+
+func (g *learnedGasFactorManager) InitializePrice(chainName, denom string, gasPrice float64) error {
+	return g.wrapped.InitializePrice(chainName, denom, gasPrice)
+}
+
+func (g *learnedGasFactorManager) GetGasPrice(chainName, denom string) (float64, error) {
+	return g.wrapped.GetGasPrice(chainName, denom)
+}
+
+// Get a gas factor, learned from recent txs if enough have been observed.
+func (g *learnedGasFactorManager) GetGasFactor(chainName, denom string) (float64, error) {
+	if g.pins.IsGasFactorPinned(chainName, denom) {
+		return g.wrapped.GetGasFactor(chainName, denom)
+	}
+
+	gasFactor, learned := g.ratios.gasFactor(gasKey{chainName: chainName, denom: denom})
+	if !learned {
+		return g.wrapped.GetGasFactor(chainName, denom)
+	}
+	return gasFactor, nil
+}
+
+// Feedback methods
+//
+// Feedback is passed through so that prices, and fallback gas factors, continue to be tracked.
+
+// Copies share learned ratios, so feedback given to a copy is kept.
+func (g *learnedGasFactorManager) withAdjustments(adjustments *gasAdjustments) (GasManager, bool) {
+	wrapped, ok := withGasAdjustments(g.wrapped, adjustments)
+	if !ok {
+		return g, false
+	}
+
+	reporting := *g
+	reporting.adjustments = adjustments
+	reporting.wrapped = wrapped
+	return &reporting, true
+}
+
+func (g *learnedGasFactorManager) ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error {
+	return g.wrapped.ManageFailingBroadcastResult(chainName, denom, broadcastResult)
+}
+
+func (g *learnedGasFactorManager) ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error {
+	if isGasUsageSample(chainName, txStatus) {
+		// Without the factor the tx was built with, assume it was the current one
+		gasFactor, err := g.GetGasFactor(chainName, denom)
+		if err != nil {
+			return err
+		}
+
+		err = g.recordRatio(chainName, denom, gasFactor, txStatus.TxResponse.GasUsed, txStatus.TxResponse.GasWanted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.wrapped.ManageIncludedTransactionStatus(chainName, denom, txStatus)
+}
+
+func (g *learnedGasFactorManager) manageIncludedTransactionGasFactor(chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64) error {
+	if isGasUsageSample(chainName, txStatus) {
+		err := g.recordRatio(chainName, denom, gasFactor, txStatus.TxResponse.GasUsed, txStatus.TxResponse.GasWanted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return manageIncludedTransactionStatus(g.wrapped, chainName, denom, txStatus, gasFactor, true)
+}
+
+func (g *learnedGasFactorManager) ManageInclusionFailure(chainName, denom string) error {
+	return g.wrapped.ManageInclusionFailure(chainName, denom)
+}
+
+// Helpers
+
+func (g *learnedGasFactorManager) recordRatio(chainName, denom string, txGasFactor float64, gasUsed, gasWanted int64) error {
+	logger := g.logger.With("chain_name", chainName, "denom", denom, "tx_gas_factor", txGasFactor, "gas_used", gasUsed, "gas_wanted", gasWanted)
+
+	gasFactor, err := g.GetGasFactor(chainName, denom)
+	if err != nil {
+		return err
+	}
+
+	key := gasKey{chainName: chainName, denom: denom}
+	ratio, samples, recorded := g.ratios.record(key, txGasFactor, gasWanted, gasUsed)
+	if !recorded {
+		logger.Debug("tx did not report gas usage, not learning gas factor")
+		return nil
+	}
+	logger.Debug("recorded ratio of used to simulated gas", "ratio", ratio, "samples", samples)
+
+	// Pinned factors are not learned, so the new ratio only changes the factor if it is not pinned
+	learnedGasFactor, learned := g.ratios.gasFactor(key)
+	if learned && learnedGasFactor != gasFactor && !g.pins.IsGasFactorPinned(chainName, denom) {
+		g.adjustments.record(chainName, denom, GasFactorChange, gasFactor, learnedGasFactor, fmt.Sprintf("learned from %d samples", samples))
+	}
+	return nil
+}
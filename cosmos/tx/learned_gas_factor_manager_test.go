@@ -0,0 +1,95 @@
+package tx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+func TestLearnedGasFactorManager_LearnsFromGasUsed(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+
+	gasManager, err := tx.NewLearnedGasFactorManager(100, 0.05, 10, 2, wrapped, nil, log.Default())
+	require.NoError(t, err)
+
+	// Without samples, the wrapped factor is used
+	gasFactor, err := gasManager.GetGasFactor("chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 1.1, gasFactor)
+
+	// Txs which used their simulated gas exactly. Gas wanted is simulated gas scaled by 1.1.
+	for i := 0; i < 2; i++ {
+		status := &txtypes.GetTxResponse{
+			TxResponse: &sdk.TxResponse{Code: 0, GasUsed: 100_000, GasWanted: 110_000},
+		}
+		require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain", "ufoo", status))
+	}
+
+	gasFactor, err = gasManager.GetGasFactor("chain", "ufoo")
+	require.NoError(t, err)
+	require.InDelta(t, 1.05, gasFactor, 0.0001)
+
+	// Other chains are unaffected
+	gasFactor, err = gasManager.GetGasFactor("other-chain", "ufoo")
+	require.NoError(t, err)
+	require.Equal(t, 1.1, gasFactor)
+}
+
+func TestLearnedGasFactorManager_InvalidParameters(t *testing.T) {
+	t.Parallel()
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+
+	_, err = tx.NewLearnedGasFactorManager(95, 0.05, 10, 20, wrapped, nil, log.Default())
+	require.Error(t, err)
+}
+
+func TestLearnedGasFactorManager_LearnsFromSigningGasFactor(t *testing.T) {
+	rpcClient := &profiledRpcClient{}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+	gasManager, err := tx.NewLearnedGasFactorManager(100, 0.05, 10, 1, wrapped, nil, log.Default())
+	require.NoError(t, err)
+
+	// The learned factor rises to 3.05 while the tx, built with the default factor of 1.1, is in flight
+	hooks := &signedHooks{signed: func() {
+		status := &txtypes.GetTxResponse{
+			TxResponse: &sdk.TxResponse{Code: 0, GasUsed: 300_000, GasWanted: 110_000},
+		}
+		require.NoError(t, gasManager.ManageIncludedTransactionStatus("chain", "ufoo", status))
+	}}
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithHooks(hooks),
+	)
+	require.NoError(t, err)
+
+	_, err = broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+
+	// The tx used 1.8 times the gas it wanted, so 1.98 times its simulation at the factor it was built with
+	gasFactor, err := gasManager.GetGasFactor("chain", "ufoo")
+	require.NoError(t, err)
+	require.InDelta(t, 3.05, gasFactor, 0.0001)
+}
@@ -0,0 +1,391 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// Percentiles of recent gas prices which are recommended
+const (
+	lowGasPricePercentile    float64 = 25
+	medianGasPricePercentile float64 = 50
+	highGasPricePercentile   float64 = 75
+)
+
+// GasPriceLevel selects a recommendation from a GasPriceEstimate.
+type GasPriceLevel string
+
+const (
+	LowGasPriceLevel    GasPriceLevel = "low"
+	MedianGasPriceLevel GasPriceLevel = "median"
+	HighGasPriceLevel   GasPriceLevel = "high"
+)
+
+// GasPriceEstimate summarizes the gas prices paid in a denom in recent blocks.
+type GasPriceEstimate struct {
+	Denom string
+
+	// Percentiles of gas prices paid
+	Low    float64
+	Median float64
+	High   float64
+
+	// Number of txs which paid fees in the denom
+	Samples int
+
+	// The blocks scanned, and when
+	FromHeight int64
+	ToHeight   int64
+	Timestamp  time.Time
+}
+
+// Price returns the recommended price for a level.
+func (e *GasPriceEstimate) Price(level GasPriceLevel) (float64, error) {
+	switch level {
+	case LowGasPriceLevel:
+		return e.Low, nil
+	case MedianGasPriceLevel:
+		return e.Median, nil
+	case HighGasPriceLevel:
+		return e.High, nil
+	}
+	return 0, fmt.Errorf("unknown gas price level: %s", level)
+}
+
+// MarketGasPriceEstimator estimates gas prices from the fees paid by txs in recent blocks of a chain.
+type MarketGasPriceEstimator interface {
+	// Get an estimate for a denom. Returns an error if no recent txs paid fees in the denom.
+	EstimateGasPrice(ctx context.Context, denom string) (*GasPriceEstimate, error)
+}
+
+// Estimator which scans recent blocks, at most once per interval.
+//
+// The gas price paid by a tx is its fee divided by its gas limit. Txs paying fees in several denoms contribute a price for each denom.
+//
+// Only one caller scans at a time, and callers needing a scan while one is in progress wait for its result. Failed scans count as scans, so
+// their error is returned until the interval passes, rather than every caller retrying a scan which may take a long time to fail.
+type marketGasPriceEstimator struct {
+	// Parameters
+	blocks   int
+	interval time.Duration
+
+	// Estimates from the last scan, keyed by denom, or the error it failed with
+	estimates   map[string]*GasPriceEstimate
+	scanErr     error
+	lastScanned time.Time
+
+	// The scan in progress, or nil
+	inProgress *marketGasPriceScan
+
+	lock *sync.Mutex
+
+	// Services
+	logger    *log.Logger
+	rpcClient rpc.RpcClient
+}
+
+var _ MarketGasPriceEstimator = (*marketGasPriceEstimator)(nil)
+
+// NewMarketGasPriceEstimator creates an estimator which scans the last blocks blocks, at most once per interval.
+func NewMarketGasPriceEstimator(blocks int, interval time.Duration, rpcClient rpc.RpcClient, logger *log.Logger) (MarketGasPriceEstimator, error) {
+	if blocks <= 0 {
+		return nil, fmt.Errorf("invalid blocks: %d. Must conform to: blocks > 0", blocks)
+	}
+
+	return &marketGasPriceEstimator{
+		blocks:   blocks,
+		interval: interval,
+
+		estimates: make(map[string]*GasPriceEstimate),
+		lock:      &sync.Mutex{},
+
+		logger:    logger.ApplyPrefix("⛽️"),
+		rpcClient: rpcClient,
+	}, nil
+}
+
+// A scan of recent blocks, which callers needing estimates while it is in progress wait for
+type marketGasPriceScan struct {
+	// Closed when the scan finishes
+	done chan struct{}
+
+	estimates map[string]*GasPriceEstimate
+	err       error
+
+	// Whether the scan was cut short by the scanning caller's context, which says nothing about the chain
+	canceled bool
+}
+
+func (e *marketGasPriceEstimator) EstimateGasPrice(ctx context.Context, denom string) (*GasPriceEstimate, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		e.lock.Lock()
+		isStale := e.lastScanned.IsZero() || time.Since(e.lastScanned) >= e.interval
+		if !isStale {
+			estimates := e.estimates
+			scanErr := e.scanErr
+			e.lock.Unlock()
+
+			return e.estimateFor(denom, estimates, scanErr)
+		}
+
+		// Rescan if the cached estimates are stale, unless another caller already is. Blocks are fetched without holding the lock, so that
+		// callers are not stuck behind a slow scan for longer than the scan takes.
+		scan := e.inProgress
+		if scan == nil {
+			scan = &marketGasPriceScan{done: make(chan struct{})}
+			e.inProgress = scan
+			e.lock.Unlock()
+
+			e.rescan(ctx, scan)
+		} else {
+			e.lock.Unlock()
+
+			select {
+			case <-scan.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		// Canceled scans are retried by the next caller
+		if !scan.canceled {
+			return e.estimateFor(denom, scan.estimates, scan.err)
+		}
+	}
+}
+
+// Scan recent blocks and store the result, then release callers waiting for the scan.
+func (e *marketGasPriceEstimator) rescan(ctx context.Context, scan *marketGasPriceScan) {
+	scan.estimates, scan.err = e.scan(ctx)
+	scan.canceled = scan.err != nil && ctx.Err() != nil
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	defer close(scan.done)
+	e.inProgress = nil
+
+	if scan.canceled {
+		return
+	}
+	if scan.err != nil {
+		e.logger.Warn("failed to scan recent blocks for gas prices, will not retry until the interval passes", "interval", e.interval, "error", scan.err.Error())
+	}
+
+	e.estimates = scan.estimates
+	e.scanErr = scan.err
+	e.lastScanned = time.Now()
+}
+
+// Get the estimate for denom from the result of a scan.
+func (e *marketGasPriceEstimator) estimateFor(denom string, estimates map[string]*GasPriceEstimate, scanErr error) (*GasPriceEstimate, error) {
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	estimate, found := estimates[denom]
+	if !found {
+		return nil, fmt.Errorf("no txs paid fees in %s in the last %d blocks", denom, e.blocks)
+	}
+	return estimate, nil
+}
+
+// Scan recent blocks and estimate prices for each denom fees were paid in.
+func (e *marketGasPriceEstimator) scan(ctx context.Context) (map[string]*GasPriceEstimate, error) {
+	toHeight, err := e.rpcClient.GetLatestBlockHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fromHeight := toHeight - int64(e.blocks) + 1
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+	logger := e.logger.With("from_height", fromHeight, "to_height", toHeight)
+
+	prices := make(map[string][]float64)
+	for height := fromHeight; height <= toHeight; height++ {
+		txs, err := e.rpcClient.GetTxsInBlock(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range txs {
+			addGasPrices(prices, tx)
+		}
+	}
+
+	timestamp := time.Now()
+	estimates := make(map[string]*GasPriceEstimate)
+	for denom, denomPrices := range prices {
+		estimates[denom] = &GasPriceEstimate{
+			Denom: denom,
+
+			Low:    percentileOf(denomPrices, lowGasPricePercentile),
+			Median: percentileOf(denomPrices, medianGasPricePercentile),
+			High:   percentileOf(denomPrices, highGasPricePercentile),
+
+			Samples: len(denomPrices),
+
+			FromHeight: fromHeight,
+			ToHeight:   toHeight,
+			Timestamp:  timestamp,
+		}
+		logger.Debug("estimated gas prices from recent blocks", "denom", denom, "low", estimates[denom].Low, "median", estimates[denom].Median, "high", estimates[denom].High, "samples", len(denomPrices))
+	}
+
+	return estimates, nil
+}
+
+// Add the gas prices a tx paid to prices, keyed by denom.
+func addGasPrices(prices map[string][]float64, tx *txtypes.Tx) {
+	if tx == nil || tx.AuthInfo == nil || tx.AuthInfo.Fee == nil || tx.AuthInfo.Fee.GasLimit == 0 {
+		return
+	}
+
+	gasLimit := float64(tx.AuthInfo.Fee.GasLimit)
+	for _, coin := range tx.AuthInfo.Fee.Amount {
+		if !coin.Amount.IsPositive() {
+			continue
+		}
+
+		// Amounts may not fit in an int64, for instance with 18 decimal denoms
+		amount, err := sdk.NewDecFromInt(coin.Amount).Float64()
+		if err != nil {
+			continue
+		}
+		prices[coin.Denom] = append(prices[coin.Denom], amount/gasLimit)
+	}
+}
+
+// Gas manager which prices txs from recent blocks.
+//
+// Prices for the configured chain are the higher of the estimator's recommendation at a level and the wrapped gas manager's price. The
+// estimate keeps prices in line with the market, while the wrapped gas manager still raises prices when fee feedback says the market
+// price is not enough. Gas factors and feedback are delegated to the wrapped gas manager. If no estimate is available, or an operator has
+// pinned the price, the wrapped gas manager's price is used.
+type marketGasManager struct {
+	// Parameters
+	chainName string
+	level     GasPriceLevel
+
+	// Values which operators have pinned. May be nil.
+	pins *GasPins
+
+	// Services
+	estimator MarketGasPriceEstimator
+	logger    *log.Logger
+	wrapped   GasManager
+}
+
+var (
+	_ adjustmentReportingGasManager = (*marketGasManager)(nil)
+	_ gasUsageLearningGasManager    = (*marketGasManager)(nil)
+)
+
+// How long to wait for an estimate
+const marketEstimateTimeout = 30 * time.Second
+
+// NewMarketGasManager creates a gas manager that prices txs for chainName from recent blocks. Other chains, and prices pinned in pins, pass
+// through to wrapped. pins may be nil.
+func NewMarketGasManager(
+	chainName string,
+	level GasPriceLevel,
+	estimator MarketGasPriceEstimator,
+	wrapped GasManager,
+	pins *GasPins,
+	logger *log.Logger,
+) (GasManager, error) {
+	_, err := (&GasPriceEstimate{}).Price(level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &marketGasManager{
+		chainName: chainName,
+		level:     level,
+
+		pins: pins,
+
+		estimator: estimator,
+		logger:    logger.ApplyPrefix("⛽️"),
+		wrapped:   wrapped,
+	}, nil
+}
+
+func (g *marketGasManager) InitializePrice(chainName, denom string, gasPrice float64) error {
+	return g.wrapped.InitializePrice(chainName, denom, gasPrice)
+}
+
+// Get a gas price, estimated from recent blocks if chainName is managed by the estimator.
+func (g *marketGasManager) GetGasPrice(chainName, denom string) (float64, error) {
+	wrappedGasPrice, err := g.wrapped.GetGasPrice(chainName, denom)
+	if err != nil || chainName != g.chainName || g.pins.IsGasPricePinned(chainName, denom) {
+		return wrappedGasPrice, err
+	}
+	logger := g.logger.With("chain_name", chainName, "denom", denom, "level", g.level)
+
+	ctx, cancel := context.WithTimeout(context.Background(), marketEstimateTimeout)
+	defer cancel()
+
+	estimate, err := g.estimator.EstimateGasPrice(ctx, denom)
+	if err != nil {
+		logger.Warn("unable to estimate gas price from recent blocks, falling back to wrapped gas manager", "error", err.Error())
+		return wrappedGasPrice, nil
+	}
+
+	estimatedGasPrice, err := estimate.Price(g.level)
+	if err != nil {
+		return 0, err
+	}
+	logger.Debug("estimated gas price from recent blocks", "estimated_gas_price", estimatedGasPrice, "wrapped_gas_price", wrappedGasPrice, "samples", estimate.Samples)
+
+	return math.Max(estimatedGasPrice, wrappedGasPrice), nil
+}
+
+func (g *marketGasManager) GetGasFactor(chainName, denom string) (float64, error) {
+	return g.wrapped.GetGasFactor(chainName, denom)
+}
+
+// Feedback methods
+//
+// Feedback is passed through so that gas factors and fallback prices continue to be tracked.
+
+func (g *marketGasManager) withAdjustments(adjustments *gasAdjustments) (GasManager, bool) {
+	wrapped, ok := withGasAdjustments(g.wrapped, adjustments)
+	if !ok {
+		return g, false
+	}
+
+	reporting := *g
+	reporting.wrapped = wrapped
+	return &reporting, true
+}
+
+func (g *marketGasManager) ManageFailingBroadcastResult(chainName, denom string, broadcastResult *txtypes.BroadcastTxResponse) error {
+	return g.wrapped.ManageFailingBroadcastResult(chainName, denom, broadcastResult)
+}
+
+func (g *marketGasManager) ManageIncludedTransactionStatus(chainName, denom string, txStatus *txtypes.GetTxResponse) error {
+	return g.wrapped.ManageIncludedTransactionStatus(chainName, denom, txStatus)
+}
+
+func (g *marketGasManager) manageIncludedTransactionGasFactor(chainName, denom string, txStatus *txtypes.GetTxResponse, gasFactor float64) error {
+	return manageIncludedTransactionStatus(g.wrapped, chainName, denom, txStatus, gasFactor, true)
+}
+
+func (g *marketGasManager) ManageInclusionFailure(chainName, denom string) error {
+	return g.wrapped.ManageInclusionFailure(chainName, denom)
+}
@@ -0,0 +1,212 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// blocksRpcClient serves fixed blocks. Other RPCs are not implemented.
+type blocksRpcClient struct {
+	rpc.RpcClient
+
+	blocks        map[int64][]*txtypes.Tx
+	latestHeight  int64
+	heightQueries int
+}
+
+func (c *blocksRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	c.heightQueries++
+	return c.latestHeight, nil
+}
+
+func (c *blocksRpcClient) GetTxsInBlock(ctx context.Context, height int64) ([]*txtypes.Tx, error) {
+	return c.blocks[height], nil
+}
+
+func txWithFee(gasLimit uint64, fee sdk.Coins) *txtypes.Tx {
+	return &txtypes.Tx{
+		AuthInfo: &txtypes.AuthInfo{Fee: &txtypes.Fee{Amount: fee, GasLimit: gasLimit}},
+	}
+}
+
+func TestMarketGasPriceEstimator_EstimatesFromRecentBlocks(t *testing.T) {
+	rpcClient := &blocksRpcClient{
+		latestHeight: 10,
+		blocks: map[int64][]*txtypes.Tx{
+			// Outside of the window
+			8: {txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 100_000)))},
+			9: {
+				txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 1))),
+				txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 2))),
+			},
+			10: {
+				txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 3))),
+				txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 4), sdk.NewInt64Coin("uosmo", 50))),
+			},
+		},
+	}
+
+	estimator, err := tx.NewMarketGasPriceEstimator(2, time.Hour, rpcClient, log.Default())
+	require.NoError(t, err)
+
+	estimate, err := estimator.EstimateGasPrice(context.Background(), "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 4, estimate.Samples)
+	require.Equal(t, 0.01, estimate.Low)
+	require.Equal(t, 0.02, estimate.Median)
+	require.Equal(t, 0.03, estimate.High)
+	require.Equal(t, int64(9), estimate.FromHeight)
+
+	estimate, err = estimator.EstimateGasPrice(context.Background(), "uosmo")
+	require.NoError(t, err)
+	require.Equal(t, 0.5, estimate.Median)
+
+	_, err = estimator.EstimateGasPrice(context.Background(), "ujuno")
+	require.Error(t, err)
+
+	// Blocks are scanned once per interval
+	require.Equal(t, 1, rpcClient.heightQueries)
+}
+
+// slowBlocksRpcClient serves one block once released, and counts scans. If err is set, height queries fail with it instead.
+type slowBlocksRpcClient struct {
+	rpc.RpcClient
+
+	err     error
+	release chan struct{}
+
+	heightQueries int
+	lock          sync.Mutex
+}
+
+func (c *slowBlocksRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	c.lock.Lock()
+	c.heightQueries++
+	c.lock.Unlock()
+
+	if c.release != nil {
+		<-c.release
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	return 1, nil
+}
+
+func (c *slowBlocksRpcClient) GetTxsInBlock(ctx context.Context, height int64) ([]*txtypes.Tx, error) {
+	return []*txtypes.Tx{txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 2)))}, nil
+}
+
+func (c *slowBlocksRpcClient) queries() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.heightQueries
+}
+
+func TestMarketGasPriceEstimator_ScansOnceForConcurrentCallers(t *testing.T) {
+	rpcClient := &slowBlocksRpcClient{release: make(chan struct{})}
+	estimator, err := tx.NewMarketGasPriceEstimator(1, time.Hour, rpcClient, log.Default())
+	require.NoError(t, err)
+
+	const callers = 5
+	results := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			estimate, err := estimator.EstimateGasPrice(context.Background(), "uatom")
+			if err == nil && estimate.Median != 0.02 {
+				err = fmt.Errorf("unexpected median: %f", estimate.Median)
+			}
+			results <- err
+		}()
+	}
+
+	// Let every caller arrive while the first scan is in progress
+	require.Eventually(t, func() bool { return rpcClient.queries() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(rpcClient.release)
+
+	for i := 0; i < callers; i++ {
+		require.NoError(t, <-results)
+	}
+	require.Equal(t, 1, rpcClient.queries())
+}
+
+func TestMarketGasPriceEstimator_SpacesOutFailedScans(t *testing.T) {
+	rpcClient := &slowBlocksRpcClient{err: errors.New("node unavailable")}
+	estimator, err := tx.NewMarketGasPriceEstimator(1, time.Hour, rpcClient, log.Default())
+	require.NoError(t, err)
+
+	// The failure is returned until the interval passes, without scanning again
+	for i := 0; i < 3; i++ {
+		_, err = estimator.EstimateGasPrice(context.Background(), "uatom")
+		require.ErrorContains(t, err, "node unavailable")
+	}
+	require.Equal(t, 1, rpcClient.queries())
+}
+
+func TestMarketGasManager_FallsBackWithoutEstimate(t *testing.T) {
+	rpcClient := &blocksRpcClient{latestHeight: 1, blocks: map[int64][]*txtypes.Tx{}}
+	estimator, err := tx.NewMarketGasPriceEstimator(5, time.Hour, rpcClient, log.Default())
+	require.NoError(t, err)
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+	require.NoError(t, wrapped.InitializePrice("chain", "uatom", 0.025))
+
+	gasManager, err := tx.NewMarketGasManager("chain", tx.HighGasPriceLevel, estimator, wrapped, nil, log.Default())
+	require.NoError(t, err)
+
+	gasPrice, err := gasManager.GetGasPrice("chain", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.025, gasPrice)
+}
+
+func TestMarketGasManager_FeeFeedbackRaisesPriceAboveEstimate(t *testing.T) {
+	rpcClient := &blocksRpcClient{
+		latestHeight: 1,
+		blocks: map[int64][]*txtypes.Tx{
+			1: {txWithFee(100, sdk.NewCoins(sdk.NewInt64Coin("uatom", 2)))},
+		},
+	}
+	estimator, err := tx.NewMarketGasPriceEstimator(5, time.Hour, rpcClient, log.Default())
+	require.NoError(t, err)
+
+	provider, err := tx.NewInMemoryGasPriceProvider()
+	require.NoError(t, err)
+	wrapped, err := tx.NewGeometricGasManager(0.001, 0.01, 0.2, provider, log.Default())
+	require.NoError(t, err)
+	require.NoError(t, wrapped.InitializePrice("chain", "uatom", 0.01))
+
+	gasManager, err := tx.NewMarketGasManager("chain", tx.MedianGasPriceLevel, estimator, wrapped, nil, log.Default())
+	require.NoError(t, err)
+
+	// The market price is higher than the wrapped price
+	gasPrice, err := gasManager.GetGasPrice("chain", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, 0.02, gasPrice)
+
+	// Fee feedback pushes the wrapped price above the market price, and is not ignored
+	wrappedGasPrice := 0.0
+	for wrappedGasPrice <= 0.02 {
+		require.NoError(t, gasManager.ManageInclusionFailure("chain", "uatom"))
+		wrappedGasPrice, err = wrapped.GetGasPrice("chain", "uatom")
+		require.NoError(t, err)
+	}
+	gasPrice, err = gasManager.GetGasPrice("chain", "uatom")
+	require.NoError(t, err)
+	require.Equal(t, wrappedGasPrice, gasPrice)
+}
@@ -0,0 +1,248 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tessellated-io/pickaxe/arrays"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MsgBatchLimits bounds the txs a MsgBatcher creates.
+type MsgBatchLimits struct {
+	// Most messages in a tx. Required.
+	MaxMsgs int
+
+	// Most gas a tx may want, after applying the gas factor. Zero is unlimited.
+	MaxGas int64
+
+	// Largest encoded tx, in bytes. Zero is unlimited.
+	MaxTxBytes int
+}
+
+// MsgResult is the outcome of a single message sent by a MsgBatcher.
+type MsgResult struct {
+	// The hash of the tx the message was sent in, if it was sent
+	TxHash string
+
+	Err error
+}
+
+// MsgBatcher packs messages into as few txs as fit within limits, and broadcasts them.
+//
+// Messages are split into batches of MaxMsgs, and each batch is measured by building and simulating it. Batches which want too much gas,
+// are too large, or fail simulation are split in half until they fit. A single message which still does not fit fails on its own, without
+// affecting other messages. Batches are only simulated while packing, so each tx is signed once, when it is broadcast.
+type MsgBatcher struct {
+	// Parameters
+	bech32Prefix string
+	chainName    string
+	feeDenom     string
+	limits       MsgBatchLimits
+	signer       crypto.BytesSigner
+
+	// Services
+	broadcaster             MsgBroadcaster
+	gasManager              GasManager
+	logger                  *log.Logger
+	signingMetadataProvider *SigningMetadataProvider
+	txSimulator             TxSimulator
+}
+
+// NewMsgBatcher creates a batcher which measures txs with txProvider, as signer, and broadcasts them with broadcaster. txProvider must be a
+// TxSimulator.
+func NewMsgBatcher(
+	chainName string,
+	bech32Prefix string,
+	signer crypto.BytesSigner,
+	limits MsgBatchLimits,
+	broadcaster MsgBroadcaster,
+	gasManager GasManager,
+	logger *log.Logger,
+	signingMetadataProvider *SigningMetadataProvider,
+	txProvider TxProvider,
+) (*MsgBatcher, error) {
+	if limits.MaxMsgs <= 0 {
+		return nil, fmt.Errorf("invalid max msgs: %d. Must conform to: max_msgs > 0", limits.MaxMsgs)
+	}
+	if limits.MaxGas < 0 {
+		return nil, fmt.Errorf("invalid max gas: %d. Must conform to: max_gas >= 0", limits.MaxGas)
+	}
+	if limits.MaxTxBytes < 0 {
+		return nil, fmt.Errorf("invalid max tx bytes: %d. Must conform to: max_tx_bytes >= 0", limits.MaxTxBytes)
+	}
+	txSimulator, ok := txProvider.(TxSimulator)
+	if !ok {
+		return nil, fmt.Errorf("msg batcher requires a tx provider which can simulate txs without signing them")
+	}
+
+	return &MsgBatcher{
+		bech32Prefix: bech32Prefix,
+		chainName:    chainName,
+		feeDenom:     txProvider.GetFeeDenom(),
+		limits:       limits,
+		signer:       signer,
+
+		broadcaster:             broadcaster,
+		gasManager:              gasManager,
+		logger:                  logger.With("chain_name", chainName),
+		signingMetadataProvider: signingMetadataProvider,
+		txSimulator:             txSimulator,
+	}, nil
+}
+
+// A batch of messages, and their indices in the caller's messages
+type msgBatch struct {
+	indices []int
+	msgs    []sdk.Msg
+}
+
+// BatchAndBroadcast packs msgs into txs and broadcasts them, one at a time. Returns a result for each message, in the same order.
+func (b *MsgBatcher) BatchAndBroadcast(ctx context.Context, msgs []sdk.Msg) ([]*MsgResult, error) {
+	results := make([]*MsgResult, len(msgs))
+	for i := range results {
+		results[i] = &MsgResult{}
+	}
+
+	batches, err := b.batch(ctx, msgs, results)
+	if err != nil {
+		return nil, err
+	}
+	b.logger.Info("📦 batched messages", "msgs", len(msgs), "txs", len(batches))
+
+	for i, batch := range batches {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		txHash, err := b.broadcaster.SignAndBroadcast(ctx, batch.msgs)
+		if err != nil {
+			b.logger.Error("failed to broadcast batch", "batch", i+1, "batches", len(batches), "msgs", len(batch.msgs), "error", err.Error())
+		}
+
+		for _, index := range batch.indices {
+			results[index].TxHash = txHash
+			results[index].Err = err
+		}
+	}
+
+	return results, nil
+}
+
+// Batch packs msgs into txs without broadcasting them. Messages which do not fit in a tx on their own are left out.
+func (b *MsgBatcher) Batch(ctx context.Context, msgs []sdk.Msg) ([][]sdk.Msg, error) {
+	results := make([]*MsgResult, len(msgs))
+	for i := range results {
+		results[i] = &MsgResult{}
+	}
+
+	batches, err := b.batch(ctx, msgs, results)
+	if err != nil {
+		return nil, err
+	}
+
+	batchedMsgs := make([][]sdk.Msg, 0, len(batches))
+	for _, batch := range batches {
+		batchedMsgs = append(batchedMsgs, batch.msgs)
+	}
+	return batchedMsgs, nil
+}
+
+// Split msgs into batches which fit the limits. Messages which do not fit have their error recorded in results.
+func (b *MsgBatcher) batch(ctx context.Context, msgs []sdk.Msg, results []*MsgResult) ([]*msgBatch, error) {
+	// Txs are measured with current gas parameters and signing metadata, which are the same for every batch
+	gasPrice, err := b.gasManager.GetGasPrice(b.chainName, b.feeDenom)
+	if err != nil {
+		return nil, err
+	}
+	gasFactor, err := b.gasManager.GetGasFactor(b.chainName, b.feeDenom)
+	if err != nil {
+		return nil, err
+	}
+	signingMetadata, err := b.signingMetadataProvider.SigningMetadataForAccount(ctx, b.signer.GetAddress(b.bech32Prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]int, len(msgs))
+	for i := range msgs {
+		indices[i] = i
+	}
+
+	batches := []*msgBatch{}
+	for _, batchIndices := range arrays.Batch(indices, b.limits.MaxMsgs) {
+		fitted, err := b.fit(ctx, gasPrice, gasFactor, signingMetadata, msgs, batchIndices, results)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, fitted...)
+	}
+
+	return batches, nil
+}
+
+// Split the messages at indices into batches which fit the limits, halving batches which do not fit. Messages which do not fit on their own
+// have their error recorded in results.
+func (b *MsgBatcher) fit(
+	ctx context.Context,
+	gasPrice, gasFactor float64,
+	signingMetadata *SigningMetadata,
+	msgs []sdk.Msg,
+	indices []int,
+	results []*MsgResult,
+) ([]*msgBatch, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	batch := &msgBatch{
+		indices: indices,
+		msgs:    make([]sdk.Msg, 0, len(indices)),
+	}
+	for _, index := range indices {
+		batch.msgs = append(batch.msgs, msgs[index])
+	}
+
+	fitErr := b.measure(ctx, gasPrice, gasFactor, signingMetadata, batch.msgs)
+	if fitErr == nil {
+		return []*msgBatch{batch}, nil
+	}
+
+	logger := b.logger.With("msgs", len(indices), "reason", fitErr.Error())
+	if len(indices) == 1 {
+		logger.Warn("message does not fit in a tx on its own, dropping it")
+		results[indices[0]].Err = fitErr
+		return []*msgBatch{}, nil
+	}
+	logger.Debug("batch does not fit in a tx, splitting it in half")
+
+	half := len(indices) / 2
+	first, err := b.fit(ctx, gasPrice, gasFactor, signingMetadata, msgs, indices[:half], results)
+	if err != nil {
+		return nil, err
+	}
+	second, err := b.fit(ctx, gasPrice, gasFactor, signingMetadata, msgs, indices[half:], results)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// Build and simulate a tx for msgs, returning an error if it fails or exceeds the limits.
+func (b *MsgBatcher) measure(ctx context.Context, gasPrice, gasFactor float64, signingMetadata *SigningMetadata, msgs []sdk.Msg) error {
+	txSize, gasWanted, err := b.txSimulator.SimulateMsgs(ctx, gasPrice, gasFactor, msgs, signingMetadata)
+	if err != nil {
+		return err
+	}
+
+	if b.limits.MaxGas > 0 && gasWanted > b.limits.MaxGas {
+		return fmt.Errorf("tx wants %d gas, exceeding max gas of %d", gasWanted, b.limits.MaxGas)
+	}
+	if b.limits.MaxTxBytes > 0 && txSize > b.limits.MaxTxBytes {
+		return fmt.Errorf("tx is %d bytes, exceeding max tx bytes of %d", txSize, b.limits.MaxTxBytes)
+	}
+	return nil
+}
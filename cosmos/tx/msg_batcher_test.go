@@ -0,0 +1,115 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// gasPerMsgTxProvider wants 100 gas per message, and fails simulation if any message is sent to "bad". It counts simulations, and refuses
+// to sign.
+type gasPerMsgTxProvider struct {
+	simulations int
+}
+
+func (p *gasPerMsgTxProvider) GetFeeDenom() string {
+	return "ufoo"
+}
+
+func (p *gasPerMsgTxProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *tx.SigningMetadata) ([]byte, int64, error) {
+	return nil, 0, errors.New("batcher signed a tx")
+}
+
+func (p *gasPerMsgTxProvider) SimulateMsgs(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *tx.SigningMetadata) (int, int64, error) {
+	p.simulations++
+	for _, msg := range messages {
+		if msg.(*banktypes.MsgSend).ToAddress == "bad" {
+			return 0, 0, errors.New("simulation failed")
+		}
+	}
+	return 10 * len(messages), int64(100 * len(messages)), nil
+}
+
+// recordingBroadcaster records the txs it is asked to send. If landing is set, each tx waits for a value from it before landing.
+type recordingBroadcaster struct {
+	batches [][]sdk.Msg
+	landing chan struct{}
+	lock    sync.Mutex
+}
+
+func (b *recordingBroadcaster) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (string, error) {
+	b.lock.Lock()
+	b.batches = append(b.batches, msgs)
+	txHash := fmt.Sprintf("hash-%d", len(b.batches))
+	b.lock.Unlock()
+
+	if b.landing != nil {
+		select {
+		case <-b.landing:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return txHash, nil
+}
+
+func (b *recordingBroadcaster) sent() [][]sdk.Msg {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([][]sdk.Msg{}, b.batches...)
+}
+
+func TestMsgBatcher_SplitsBatchesToFit(t *testing.T) {
+	rpcClient := &sequenceRpcClient{}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	broadcaster := &recordingBroadcaster{}
+	txProvider := &gasPerMsgTxProvider{}
+
+	limits := tx.MsgBatchLimits{MaxMsgs: 4, MaxGas: 250, MaxTxBytes: 1_000}
+	batcher, err := tx.NewMsgBatcher(
+		"chain", "cosmos", &addressSigner{}, limits, broadcaster, newBacktestGasManager(t, 0.01), log.Default(),
+		signingMetadataProvider, txProvider,
+	)
+	require.NoError(t, err)
+
+	msgs := []sdk.Msg{}
+	for i := 0; i < 10; i++ {
+		toAddress := fmt.Sprintf("address-%d", i)
+		if i == 5 {
+			toAddress = "bad"
+		}
+		msgs = append(msgs, &banktypes.MsgSend{ToAddress: toAddress})
+	}
+
+	results, err := batcher.BatchAndBroadcast(context.Background(), msgs)
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+
+	// Batches of four want too much gas, so are halved. The batch with the bad message is halved again.
+	batchSizes := []int{}
+	for _, batch := range broadcaster.batches {
+		batchSizes = append(batchSizes, len(batch))
+	}
+	require.Equal(t, []int{2, 2, 1, 2, 2}, batchSizes)
+
+	require.Equal(t, "hash-1", results[0].TxHash)
+	require.Equal(t, "hash-3", results[4].TxHash)
+	require.Error(t, results[5].Err)
+	require.Empty(t, results[5].TxHash)
+	require.Equal(t, "hash-4", results[6].TxHash)
+	require.Equal(t, "hash-5", results[9].TxHash)
+
+	// Halving takes fewer simulations than there are messages
+	require.Less(t, txProvider.simulations, len(msgs))
+}
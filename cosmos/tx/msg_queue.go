@@ -0,0 +1,309 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MsgFuture is the eventual result of a submission to a MsgQueue.
+type MsgFuture struct {
+	done chan struct{}
+
+	txHash string
+	err    error
+}
+
+func newMsgFuture() *MsgFuture {
+	return &MsgFuture{
+		done: make(chan struct{}),
+	}
+}
+
+// Done is closed once the result is available.
+func (f *MsgFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the submission's tx lands or fails, or ctx ends. The tx hash is returned whenever the submission was sent in a tx,
+// even if the tx failed.
+func (f *MsgFuture) Wait(ctx context.Context) (string, error) {
+	select {
+	case <-f.done:
+		return f.txHash, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (f *MsgFuture) resolve(txHash string, err error) {
+	f.txHash = txHash
+	f.err = err
+	close(f.done)
+}
+
+// MsgSubmissionOption configures a submission to a MsgQueue.
+type MsgSubmissionOption func(*queuedSubmission)
+
+// WithPriority sends the submission ahead of submissions with lower priorities when there are more messages than fit in a tx. The default
+// priority is zero.
+func WithPriority(priority int) MsgSubmissionOption {
+	return func(s *queuedSubmission) {
+		s.priority = priority
+	}
+}
+
+// WithDeadline fails the submission with ErrSubmissionExpired if it has not been sent by deadline. The queue sends early, rather than
+// waiting for the full window, to meet deadlines.
+func WithDeadline(deadline time.Time) MsgSubmissionOption {
+	return func(s *queuedSubmission) {
+		s.deadline = deadline
+	}
+}
+
+// A submission waiting to be sent
+type queuedSubmission struct {
+	msgs     []sdk.Msg
+	priority int
+	deadline time.Time
+
+	// Order of submission, to break ties in priority
+	sequence    uint64
+	submittedAt time.Time
+
+	future *MsgFuture
+}
+
+// MsgQueue combines messages from many callers into shared txs for a single chain and signer.
+//
+// Submissions are collected until the oldest has waited for the window, or the queue holds enough messages to fill a tx, and then sent in
+// one tx. Every submission in a tx shares its result, so a failing message fails the whole tx.
+//
+// Each tx is sent in the background, so the next tx collects submissions while the last waits to land. Txs can therefore be in flight at the
+// same time, and the broadcaster should track sequences locally, for instance a Broadcaster created with WithSequenceManager.
+type MsgQueue struct {
+	// Parameters
+	maxMsgs int
+	window  time.Duration
+
+	// Submissions waiting to be sent
+	pending      []*queuedSubmission
+	nextSequence uint64
+	closed       bool
+	lock         *sync.Mutex
+
+	// Signals new submissions to the run loop
+	wake chan struct{}
+
+	// Txs which have not landed yet
+	sends *sync.WaitGroup
+
+	// Services
+	broadcaster MsgBroadcaster
+	logger      *log.Logger
+}
+
+// NewMsgQueue creates a queue which waits up to window to collect at most maxMsgs messages per tx. Txs are sent once Run is called.
+func NewMsgQueue(window time.Duration, maxMsgs int, broadcaster MsgBroadcaster, logger *log.Logger) (*MsgQueue, error) {
+	if maxMsgs <= 0 {
+		return nil, fmt.Errorf("invalid max msgs: %d. Must conform to: max_msgs > 0", maxMsgs)
+	}
+	if window < 0 {
+		return nil, fmt.Errorf("invalid window: %s. Must conform to: window >= 0", window)
+	}
+
+	return &MsgQueue{
+		maxMsgs: maxMsgs,
+		window:  window,
+
+		pending: []*queuedSubmission{},
+		lock:    &sync.Mutex{},
+
+		wake:  make(chan struct{}, 1),
+		sends: &sync.WaitGroup{},
+
+		broadcaster: broadcaster,
+		logger:      logger,
+	}, nil
+}
+
+// Submit queues msgs to be sent in a shared tx. Submissions larger than the queue's max msgs are sent in a tx of their own.
+func (q *MsgQueue) Submit(msgs []sdk.Msg, opts ...MsgSubmissionOption) *MsgFuture {
+	future := newMsgFuture()
+	submission := &queuedSubmission{
+		msgs:        msgs,
+		submittedAt: time.Now(),
+		future:      future,
+	}
+	for _, opt := range opts {
+		opt(submission)
+	}
+
+	if len(msgs) == 0 {
+		future.resolve("", ErrEmptyMsgSubmission)
+		return future
+	}
+	if !submission.deadline.IsZero() && submission.deadline.Before(submission.submittedAt) {
+		future.resolve("", ErrSubmissionExpired)
+		return future
+	}
+
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		future.resolve("", ErrMsgQueueClosed)
+		return future
+	}
+	submission.sequence = q.nextSequence
+	q.nextSequence++
+	q.pending = append(q.pending, submission)
+	q.lock.Unlock()
+
+	// Wake the run loop, unless it is already due to wake
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return future
+}
+
+// Run sends queued submissions until ctx ends. Submissions which have not been sent when ctx ends fail with ctx's error, and later
+// submissions fail with ErrMsgQueueClosed. Run returns once every tx it sent has resolved its submissions.
+func (q *MsgQueue) Run(ctx context.Context) error {
+	for {
+		flushAt, hasPending, isFull := q.schedule()
+
+		if !hasPending {
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				q.close(ctx.Err())
+				q.sends.Wait()
+				return ctx.Err()
+			}
+		}
+
+		now := time.Now()
+		if isFull || !now.Before(flushAt) {
+			q.flush(ctx, now)
+			continue
+		}
+
+		timer := time.NewTimer(flushAt.Sub(now))
+		select {
+		case <-timer.C:
+			// Flush as of the scheduled time, so submissions due at exactly that time are still sent
+			q.flush(ctx, flushAt)
+		case <-q.wake:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			q.close(ctx.Err())
+			q.sends.Wait()
+			return ctx.Err()
+		}
+	}
+}
+
+// Determine when to next send a tx: once the oldest submission has waited for the window, or in time for the earliest deadline.
+func (q *MsgQueue) schedule() (flushAt time.Time, hasPending bool, isFull bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.pending) == 0 {
+		return time.Time{}, false, false
+	}
+
+	msgs := 0
+	flushAt = q.pending[0].submittedAt.Add(q.window)
+	for _, submission := range q.pending {
+		msgs += len(submission.msgs)
+		if !submission.deadline.IsZero() && submission.deadline.Before(flushAt) {
+			flushAt = submission.deadline
+		}
+	}
+
+	return flushAt, true, msgs >= q.maxMsgs
+}
+
+// Start sending one tx containing the highest priority submissions which fit. Submissions with deadlines before asOf are expired.
+func (q *MsgQueue) flush(ctx context.Context, asOf time.Time) {
+	q.lock.Lock()
+	live := []*queuedSubmission{}
+	for _, submission := range q.pending {
+		if !submission.deadline.IsZero() && submission.deadline.Before(asOf) {
+			submission.future.resolve("", ErrSubmissionExpired)
+			continue
+		}
+		live = append(live, submission)
+	}
+
+	sort.SliceStable(live, func(i, j int) bool {
+		if live[i].priority != live[j].priority {
+			return live[i].priority > live[j].priority
+		}
+		return live[i].sequence < live[j].sequence
+	})
+
+	// Always take the first submission, even if it alone is larger than a tx should be
+	batch := []*queuedSubmission{}
+	remaining := []*queuedSubmission{}
+	msgs := []sdk.Msg{}
+	for _, submission := range live {
+		if len(batch) == 0 || len(msgs)+len(submission.msgs) <= q.maxMsgs {
+			batch = append(batch, submission)
+			msgs = append(msgs, submission.msgs...)
+		} else {
+			remaining = append(remaining, submission)
+		}
+	}
+
+	// Keep waiting submissions in submission order, so the oldest determines the next window
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].sequence < remaining[j].sequence
+	})
+	q.pending = remaining
+	q.lock.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	q.logger.Debug("sending coalesced submissions", "submissions", len(batch), "msgs", len(msgs), "waiting", len(remaining))
+	q.sends.Add(1)
+	go q.send(ctx, batch, msgs)
+}
+
+// Send msgs in a tx, and resolve the submissions in batch with the result.
+func (q *MsgQueue) send(ctx context.Context, batch []*queuedSubmission, msgs []sdk.Msg) {
+	defer q.sends.Done()
+
+	txHash, err := q.broadcaster.SignAndBroadcast(ctx, msgs)
+	if err != nil {
+		q.logger.Error("failed to send coalesced submissions", "submissions", len(batch), "error", err.Error())
+	}
+
+	for _, submission := range batch {
+		submission.future.resolve(txHash, err)
+	}
+}
+
+// Fail all waiting submissions, and reject new ones.
+func (q *MsgQueue) close(err error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, submission := range q.pending {
+		submission.future.resolve("", err)
+	}
+	q.pending = []*queuedSubmission{}
+	q.closed = true
+}
@@ -0,0 +1,113 @@
+package tx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func sendMsg(toAddress string) []sdk.Msg {
+	return []sdk.Msg{&banktypes.MsgSend{ToAddress: toAddress}}
+}
+
+func runMsgQueue(t *testing.T, queue *tx.MsgQueue) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = queue.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestMsgQueue_CoalescesSubmissions(t *testing.T) {
+	broadcaster := &recordingBroadcaster{}
+	queue, err := tx.NewMsgQueue(50*time.Millisecond, 10, broadcaster, log.Default())
+	require.NoError(t, err)
+	runMsgQueue(t, queue)
+
+	futures := []*tx.MsgFuture{queue.Submit(sendMsg("a")), queue.Submit(sendMsg("b")), queue.Submit(sendMsg("c"))}
+	for _, future := range futures {
+		txHash, err := future.Wait(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "hash-1", txHash)
+	}
+	require.Len(t, broadcaster.sent(), 1)
+	require.Len(t, broadcaster.sent()[0], 3)
+}
+
+func TestMsgQueue_CollectsWhileTxLands(t *testing.T) {
+	broadcaster := &recordingBroadcaster{landing: make(chan struct{})}
+	queue, err := tx.NewMsgQueue(10*time.Millisecond, 10, broadcaster, log.Default())
+	require.NoError(t, err)
+	runMsgQueue(t, queue)
+
+	first := queue.Submit(sendMsg("a"))
+	require.Eventually(t, func() bool { return len(broadcaster.sent()) == 1 }, time.Second, time.Millisecond)
+
+	// The first tx has not landed, but the next is still sent
+	second := queue.Submit(sendMsg("b"))
+	require.Eventually(t, func() bool { return len(broadcaster.sent()) == 2 }, time.Second, time.Millisecond)
+
+	broadcaster.landing <- struct{}{}
+	broadcaster.landing <- struct{}{}
+	for _, future := range []*tx.MsgFuture{first, second} {
+		_, err := future.Wait(context.Background())
+		require.NoError(t, err)
+	}
+}
+
+func TestMsgQueue_SendsHigherPrioritiesFirst(t *testing.T) {
+	broadcaster := &recordingBroadcaster{}
+	queue, err := tx.NewMsgQueue(time.Hour, 2, broadcaster, log.Default())
+	require.NoError(t, err)
+
+	// The queue is full before it runs, so sends without waiting for the window
+	low := queue.Submit(sendMsg("low"))
+	lower := queue.Submit(sendMsg("lower"), tx.WithPriority(-1))
+	high := queue.Submit(sendMsg("high"), tx.WithPriority(5))
+	runMsgQueue(t, queue)
+
+	txHash, err := high.Wait(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hash-1", txHash)
+	txHash, err = low.Wait(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hash-1", txHash)
+
+	// The lowest priority waits for the next tx
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = lower.Wait(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMsgQueue_MeetsDeadlines(t *testing.T) {
+	broadcaster := &recordingBroadcaster{}
+	queue, err := tx.NewMsgQueue(time.Hour, 10, broadcaster, log.Default())
+	require.NoError(t, err)
+	runMsgQueue(t, queue)
+
+	// A deadline shortens the window
+	future := queue.Submit(sendMsg("a"), tx.WithDeadline(time.Now().Add(20*time.Millisecond)))
+	txHash, err := future.Wait(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hash-1", txHash)
+
+	// Deadlines which have passed fail immediately
+	future = queue.Submit(sendMsg("b"), tx.WithDeadline(time.Now().Add(-time.Second)))
+	_, err = future.Wait(context.Background())
+	require.ErrorIs(t, err, tx.ErrSubmissionExpired)
+}
@@ -0,0 +1,352 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// PipelinedTxResult is the outcome of one tx sent by a PipelinedBroadcaster.
+type PipelinedTxResult struct {
+	// The hash of the tx, if it landed on chain. Txs which landed on chain but failed have both a hash and an error.
+	TxHash string
+
+	Err error
+}
+
+// PipelinedBroadcaster sends many txs without waiting for each to land before sending the next.
+//
+// Txs are signed with consecutive sequences and broadcast back to back, and then their inclusion is polled for together. Each round:
+//   - Txs failing CheckTx do not consume their sequence, so the txs after them are signed with the sequences they would have used.
+//   - Txs failing due to gas, either in CheckTx or on chain, are retried in the next round.
+//   - Txs which are not included after polling are waited on until the chain passes their timeout height, after which they can never land.
+//     Txs after them cannot land with a gap in sequences, so the sequence is resynced from chain and all remaining txs are re-signed in the
+//     next round.
+//
+// Other failures are returned without retrying.
+type PipelinedBroadcaster struct {
+	// Parameters
+	bech32Prefix string
+	chainName    string
+	feeDenom     string
+	signer       crypto.BytesSigner
+
+	pollAttempts uint
+	pollDelay    time.Duration
+	rounds       uint
+
+	// Services
+	gasManager      GasManager
+	logger          *log.Logger
+	sequenceManager SequenceManager
+	wrapped         *defaultBroadcaster
+}
+
+// PipelinedBroadcasterOption configures optional behavior of a PipelinedBroadcaster.
+type PipelinedBroadcasterOption func(*PipelinedBroadcaster)
+
+// WithPipelinedTimeoutBlocks sets the timeout height of pipelined txs to timeoutBlocks after the chain's current height, instead of
+// DefaultTimeoutBlocks. Txs which are not included are only re-signed once the chain passes their timeout height, so timeoutBlocks must be
+// positive.
+func WithPipelinedTimeoutBlocks(timeoutBlocks uint64) PipelinedBroadcasterOption {
+	return func(b *PipelinedBroadcaster) {
+		b.wrapped.timeoutBlocks = timeoutBlocks
+	}
+}
+
+// NewPipelinedBroadcaster creates a pipelined broadcaster. Sequences are tracked by sequenceManager, which must not be shared with a
+// broadcaster for the same account that does not also use it. Txs are sent in at most rounds rounds.
+func NewPipelinedBroadcaster(
+	chainName string,
+	bech32Prefix string,
+	signer crypto.BytesSigner,
+	gasManager GasManager,
+	logger *log.Logger,
+	rpcClient rpc.RpcClient,
+	signingMetadataProvider *SigningMetadataProvider,
+	sequenceManager SequenceManager,
+	txProvider TxProvider,
+
+	txPollAttempts uint,
+	txPollDelay time.Duration,
+
+	rounds uint,
+
+	opts ...PipelinedBroadcasterOption,
+) (*PipelinedBroadcaster, error) {
+	if sequenceManager == nil {
+		return nil, fmt.Errorf("pipelined broadcasting requires a sequence manager")
+	}
+	if rounds == 0 {
+		return nil, fmt.Errorf("invalid rounds: %d. Must conform to: rounds > 0", rounds)
+	}
+
+	wrapped := newDefaultTxBroadcaster(chainName, bech32Prefix, signer, gasManager, logger, rpcClient, signingMetadataProvider, txProvider, WithSequenceManager(sequenceManager))
+
+	broadcaster := &PipelinedBroadcaster{
+		bech32Prefix: bech32Prefix,
+		chainName:    chainName,
+		feeDenom:     txProvider.GetFeeDenom(),
+		signer:       signer,
+
+		pollAttempts: txPollAttempts,
+		pollDelay:    txPollDelay,
+		rounds:       rounds,
+
+		gasManager:      gasManager,
+		logger:          logger.With("chain_name", chainName, "fee_denom", txProvider.GetFeeDenom()),
+		sequenceManager: sequenceManager,
+		wrapped:         wrapped,
+	}
+	for _, opt := range opts {
+		opt(broadcaster)
+	}
+
+	if broadcaster.wrapped.timeoutBlocks == 0 {
+		return nil, fmt.Errorf("invalid timeout blocks: 0. Pipelined txs must time out so that dropped txs can be safely re-signed")
+	}
+
+	return broadcaster, nil
+}
+
+// A tx awaiting inclusion
+type inFlightTx struct {
+	index  int
+	txHash string
+}
+
+// SignAndBroadcastAll sends one tx for each entry in msgs, and returns a result for each, in the same order. An error is returned only if
+// ctx ends, in which case unfinished txs have ctx's error as their result.
+func (b *PipelinedBroadcaster) SignAndBroadcastAll(ctx context.Context, msgs [][]sdk.Msg) ([]*PipelinedTxResult, error) {
+	results := make([]*PipelinedTxResult, len(msgs))
+	pending := make([]int, len(msgs))
+	for i := range msgs {
+		results[i] = &PipelinedTxResult{}
+		pending[i] = i
+	}
+
+	var round uint
+	for round = 0; round < b.rounds && len(pending) > 0; round++ {
+		logger := b.logger.With("round", round+1, "max_rounds", b.rounds, "txs", len(pending))
+		logger.Info("🚇 broadcasting pipelined txs")
+
+		inFlight, retry, err := b.broadcastAll(ctx, msgs, pending, results)
+		if err != nil {
+			return results, b.abandon(err, pending, results)
+		}
+
+		notIncluded, retryAfterInclusion, err := b.pollAll(ctx, inFlight, results)
+		if err != nil {
+			return results, b.abandon(err, pending, results)
+		}
+		retry = append(retry, retryAfterInclusion...)
+
+		// Txs after a dropped tx are stuck behind a gap in sequences, so resync and re-sign them all
+		if len(notIncluded) > 0 {
+			logger.Warn("pipelined txs were not included, will re-sign", "not_included", len(notIncluded))
+			b.sequenceManager.Resync(b.signer.GetAddress(b.bech32Prefix))
+			retry = append(retry, notIncluded...)
+		}
+
+		sort.Ints(retry)
+		pending = retry
+	}
+
+	if len(pending) > 0 {
+		b.logger.Error("failed to land pipelined txs in all rounds", "txs", len(pending))
+	}
+
+	return results, nil
+}
+
+// Sign and broadcast the pending txs, in order. Returns the txs which were accepted into the mempool, and the indices of txs to retry.
+func (b *PipelinedBroadcaster) broadcastAll(ctx context.Context, msgs [][]sdk.Msg, pending []int, results []*PipelinedTxResult) ([]*inFlightTx, []int, error) {
+	inFlight := []*inFlightTx{}
+	retry := []int{}
+
+	for _, index := range pending {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		// Forget about any earlier attempt
+		results[index].TxHash = ""
+
+		result, err := b.wrapped.signAndBroadcast(ctx, msgs[index])
+		if err != nil {
+			// Nothing is known about the tx, so try again. If it did make it in to the mempool, the next tx's sequence mismatch resyncs.
+			b.logger.Error("failed to sign and broadcast pipelined tx, will retry", "index", index, "error", err.Error())
+			results[index].Err = err
+			retry = append(retry, index)
+			continue
+		}
+
+		txResponse := result.TxResponse
+		if txResponse.Code != 0 {
+			gasManagementErr := b.gasManager.ManageFailingBroadcastResult(b.chainName, b.feeDenom, result)
+			if gasManagementErr != nil {
+				b.logger.Warn("failed to adjust gas due to broadcast result", "error", gasManagementErr)
+			}
+
+			results[index].Err = abci.ForChain(b.chainName).FromTxResponse(txResponse)
+			if IsGasError(results[index].Err) {
+				b.logger.Error("pipelined tx failed due to gas, will retry", "index", index, "error", txResponse.RawLog)
+				retry = append(retry, index)
+			} else {
+				b.logger.Error("broadcasted pipelined tx, but got non-success response code", "index", index, "codespace", txResponse.Codespace, "code", txResponse.Code, "error", txResponse.RawLog)
+			}
+			continue
+		}
+
+		results[index].Err = nil
+		inFlight = append(inFlight, &inFlightTx{index: index, txHash: txResponse.TxHash})
+	}
+
+	return inFlight, retry, nil
+}
+
+// Poll for inclusion of in flight txs. Returns the indices of txs which were not included, and of included txs which should be retried.
+func (b *PipelinedBroadcaster) pollAll(ctx context.Context, inFlight []*inFlightTx, results []*PipelinedTxResult) ([]int, []int, error) {
+	retry := []int{}
+
+	var attempt uint
+	for attempt = 0; attempt < b.pollAttempts && len(inFlight) > 0; attempt++ {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		// Initially sleep to give time to settle
+		time.Sleep(b.pollDelay)
+
+		stillInFlight := []*inFlightTx{}
+		for _, tx := range inFlight {
+			txStatus, err := b.wrapped.checkTxStatus(ctx, tx.txHash)
+			if err != nil || txStatus == nil {
+				// Errors are usually transient, so keep polling
+				stillInFlight = append(stillInFlight, tx)
+				continue
+			}
+
+			retry = b.manageIncluded(tx, txStatus, results, retry)
+		}
+
+		inFlight = stillInFlight
+		b.logger.Info("pipelined txs still not included", "attempt", attempt+1, "max_attempts", b.pollAttempts, "in_flight", len(inFlight))
+	}
+
+	if len(inFlight) == 0 {
+		return []int{}, retry, nil
+	}
+
+	// Txs were likely under priced. The txs share a gas price, so the price is raised once for the round rather than once for each tx.
+	gasManagementErr := b.gasManager.ManageInclusionFailure(b.chainName, b.feeDenom)
+	if gasManagementErr != nil {
+		b.logger.Warn("failed to adjust gas due to missing tx inclusion", "error", gasManagementErr)
+	}
+
+	notIncluded, retry, err := b.awaitTimeouts(ctx, inFlight, results, retry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return notIncluded, retry, nil
+}
+
+// Wait for txs which were not found after polling to either land, or pass their timeout height so that they can never land. Returns the
+// indices of txs which timed out, and retry with included txs which should be retried added.
+func (b *PipelinedBroadcaster) awaitTimeouts(ctx context.Context, inFlight []*inFlightTx, results []*PipelinedTxResult, retry []int) ([]int, []int, error) {
+	notIncluded := []int{}
+	for len(inFlight) > 0 {
+		stillInFlight := []*inFlightTx{}
+		for _, tx := range inFlight {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+
+			// The height is read before the status, so a tx which is not found past its timeout height can never land
+			txStatus, timedOut, err := b.wrapped.checkTimedOut(ctx, tx.txHash)
+			switch {
+			case errors.Is(err, ErrNoTimeoutHeight):
+				// The tx was not signed by this broadcaster, for instance because the mempool already held its bytes, so nothing is known
+				// about when it can no longer land
+				b.logger.Warn("pipelined tx has no timeout height, assuming it was dropped", "index", tx.index, "tx_hash", tx.txHash)
+				timedOut = true
+			case err != nil:
+				// Errors are usually transient, so keep waiting
+				b.logger.Debug("failed to check whether pipelined tx timed out", "index", tx.index, "tx_hash", tx.txHash, "error", err.Error())
+				stillInFlight = append(stillInFlight, tx)
+				continue
+			}
+
+			if txStatus != nil {
+				b.logger.Info("pipelined tx landed before its timeout height", "index", tx.index, "tx_hash", tx.txHash)
+				retry = b.manageIncluded(tx, txStatus, results, retry)
+				continue
+			}
+			if timedOut {
+				results[tx.index].Err = fmt.Errorf("transaction not included before its timeout height: %s", tx.txHash)
+				notIncluded = append(notIncluded, tx.index)
+				continue
+			}
+
+			stillInFlight = append(stillInFlight, tx)
+		}
+
+		inFlight = stillInFlight
+		if len(inFlight) == 0 {
+			break
+		}
+		b.logger.Info("pipelined txs still not included, waiting for their timeout height", "in_flight", len(inFlight))
+
+		select {
+		case <-time.After(b.pollDelay):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	return notIncluded, retry, nil
+}
+
+// Record the result of an included tx, and manage gas for it. Returns retry, with the tx's index added if it failed due to gas.
+func (b *PipelinedBroadcaster) manageIncluded(tx *inFlightTx, txStatus *txtypes.GetTxResponse, results []*PipelinedTxResult, retry []int) []int {
+	gasFactor, knownGasFactor := b.wrapped.gasFactors.take(tx.txHash)
+	gasManagementErr := manageIncludedTransactionStatus(b.gasManager, b.chainName, b.feeDenom, txStatus, gasFactor, knownGasFactor)
+	if gasManagementErr != nil {
+		b.logger.Warn("failed to adjust gas due to tx status", "error", gasManagementErr)
+	}
+
+	results[tx.index].TxHash = tx.txHash
+	if IsSuccessTxStatus(txStatus) {
+		return retry
+	}
+
+	txResponse := txStatus.TxResponse
+	results[tx.index].Err = abci.ForChain(b.chainName).FromTxResponse(txResponse)
+	if IsGasError(results[tx.index].Err) {
+		b.logger.Error("pipelined tx landed on chain but failed due to gas, will retry", "index", tx.index, "tx_hash", tx.txHash, "error", txResponse.RawLog)
+		return append(retry, tx.index)
+	}
+
+	b.logger.Error("pipelined tx landed on chain but failed due to non-gas related error", "index", tx.index, "tx_hash", tx.txHash, "error", txResponse.RawLog)
+	return retry
+}
+
+// Record err as the result of unfinished txs.
+func (b *PipelinedBroadcaster) abandon(err error, unfinished []int, results []*PipelinedTxResult) error {
+	for _, index := range unfinished {
+		if results[index].TxHash == "" {
+			results[index].Err = err
+		}
+	}
+	return err
+}
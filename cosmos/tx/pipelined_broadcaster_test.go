@@ -0,0 +1,162 @@
+package tx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// flakyFeeRpcClient rejects the first tx with a sequence for insufficient fees.
+type flakyFeeRpcClient struct {
+	*sequenceRpcClient
+
+	rejectSequence string
+	rejected       bool
+}
+
+func (c *flakyFeeRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	if string(txBytes) == c.rejectSequence && !c.rejected {
+		c.rejected = true
+		return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{Codespace: "sdk", Code: 13, RawLog: "insufficient fee"}}, nil
+	}
+	return c.sequenceRpcClient.Broadcast(ctx, txBytes)
+}
+
+func TestPipelinedBroadcaster_ResignsAfterFailureInTheMiddle(t *testing.T) {
+	rpcClient := &flakyFeeRpcClient{
+		sequenceRpcClient: &sequenceRpcClient{committedSequence: 5, expectedSequence: 5},
+		rejectSequence:    "6",
+	}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	broadcaster, err := tx.NewPipelinedBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		sequenceManager, &sequenceTxProvider{}, 1, time.Millisecond, 2,
+	)
+	require.NoError(t, err)
+
+	results, err := broadcaster.SignAndBroadcastAll(context.Background(), [][]sdk.Msg{nil, nil, nil})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+	}
+
+	// The tx after the rejected one takes its sequence, and the rejected tx is re-signed with the next
+	require.Equal(t, "5", results[0].TxHash)
+	require.Equal(t, "7", results[1].TxHash)
+	require.Equal(t, "6", results[2].TxHash)
+	require.Equal(t, 1, rpcClient.accountQueries)
+}
+
+// droppingPipelineRpcClient accepts every tx, but drops the first broadcast of each of dropHashes. Every height query sees blocksPerQuery
+// more blocks.
+type droppingPipelineRpcClient struct {
+	*sequenceRpcClient
+
+	dropHashes     map[string]bool
+	height         int64
+	blocksPerQuery int64
+
+	broadcasts       map[string]int
+	broadcastHeights []int64
+}
+
+func (c *droppingPipelineRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	c.height += c.blocksPerQuery
+	return c.height, nil
+}
+
+func (c *droppingPipelineRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	c.broadcasts[string(txBytes)]++
+	c.broadcastHeights = append(c.broadcastHeights, c.height)
+	return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: string(txBytes)}}, nil
+}
+
+func (c *droppingPipelineRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	if c.dropHashes[txHash] && c.broadcasts[txHash] == 1 {
+		return nil, status.Error(codes.NotFound, "tx not found")
+	}
+	return c.sequenceRpcClient.GetTxStatus(ctx, txHash)
+}
+
+// inclusionFailureCountingGasManager counts inclusion failures.
+type inclusionFailureCountingGasManager struct {
+	tx.GasManager
+
+	inclusionFailures int
+}
+
+func (g *inclusionFailureCountingGasManager) ManageInclusionFailure(chainName, denom string) error {
+	g.inclusionFailures++
+	return g.GasManager.ManageInclusionFailure(chainName, denom)
+}
+
+func TestPipelinedBroadcaster_ResignsDroppedTxsAfterTimeout(t *testing.T) {
+	rpcClient := &droppingPipelineRpcClient{
+		sequenceRpcClient: &sequenceRpcClient{committedSequence: 5},
+		dropHashes:        map[string]bool{"5": true, "6": true},
+		height:            100,
+		blocksPerQuery:    1,
+		broadcasts:        map[string]int{},
+	}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	gasManager := &inclusionFailureCountingGasManager{GasManager: newBacktestGasManager(t, 0.01)}
+	txProvider := &timeoutTxProvider{}
+	broadcaster, err := tx.NewPipelinedBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider,
+		sequenceManager, txProvider, 1, time.Millisecond, 2, tx.WithPipelinedTimeoutBlocks(10),
+	)
+	require.NoError(t, err)
+
+	results, err := broadcaster.SignAndBroadcastAll(context.Background(), [][]sdk.Msg{nil, nil, nil})
+	require.NoError(t, err)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+	}
+
+	// The dropped txs were re-signed only once the chain passed their timeout heights
+	require.Equal(t, 2, rpcClient.broadcasts["5"])
+	require.Equal(t, 2, rpcClient.broadcasts["6"])
+	require.Equal(t, 1, rpcClient.broadcasts["7"])
+	require.Len(t, rpcClient.broadcastHeights, 5)
+	require.Greater(t, uint64(rpcClient.broadcastHeights[3]), txProvider.timeoutHeights[1])
+
+	// Gas was raised once for the round, not once for each dropped tx
+	require.Equal(t, 1, gasManager.inclusionFailures)
+}
+
+func TestPipelinedBroadcaster_RequiresTimeouts(t *testing.T) {
+	rpcClient := &sequenceRpcClient{}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	_, err = tx.NewPipelinedBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		sequenceManager, &sequenceTxProvider{}, 1, time.Millisecond, 1, tx.WithPipelinedTimeoutBlocks(0),
+	)
+	require.Error(t, err)
+}
+
+func TestPipelinedBroadcaster_RequiresSequenceManager(t *testing.T) {
+	_, err := tx.NewPipelinedBroadcaster("chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), nil, nil, nil, &sequenceTxProvider{}, 1, time.Millisecond, 1)
+	require.Error(t, err)
+}
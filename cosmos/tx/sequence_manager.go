@@ -0,0 +1,150 @@
+package tx
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/tessellated-io/pickaxe/log"
+)
+
+// SequenceManager hands out account sequences locally, so that several txs can be in flight for an account at once.
+//
+// The chain is only queried for an account's sequence the first time it is needed, and after a resync. Callers report failures so that the
+// local sequence stays in step with the chain.
+type SequenceManager interface {
+	// Get signing metadata for the next tx from address, and reserve its sequence.
+	NextSigningMetadata(ctx context.Context, address string) (*SigningMetadata, error)
+
+	// Set the next sequence to hand out for address, for instance to the sequence the chain expected in a mismatch.
+	ResetSequence(address string, nextSequence uint64)
+
+	// Give back a sequence for a tx which will never land, for instance because it failed CheckTx or timed out. If it is still the latest
+	// sequence handed out it is handed out again. Otherwise later txs were signed after it, and the account is resynced from chain.
+	ReleaseSequence(address string, sequence uint64)
+
+	// Forget what is known about address, so the next tx queries the chain.
+	Resync(address string)
+}
+
+// Sequences for an account
+type accountSequence struct {
+	accountNumber uint64
+	nextSequence  uint64
+}
+
+// Sequence manager which caches sequences in memory
+type sequenceManager struct {
+	// Accounts seen so far, keyed by address
+	accounts map[string]*accountSequence
+	lock     *sync.Mutex
+
+	// Services
+	logger                  *log.Logger
+	signingMetadataProvider *SigningMetadataProvider
+}
+
+var _ SequenceManager = (*sequenceManager)(nil)
+
+// NewSequenceManager creates a sequence manager which syncs from chain using signingMetadataProvider.
+func NewSequenceManager(signingMetadataProvider *SigningMetadataProvider, logger *log.Logger) (SequenceManager, error) {
+	return &sequenceManager{
+		accounts: make(map[string]*accountSequence),
+		lock:     &sync.Mutex{},
+
+		logger:                  logger,
+		signingMetadataProvider: signingMetadataProvider,
+	}, nil
+}
+
+func (sm *sequenceManager) NextSigningMetadata(ctx context.Context, address string) (*SigningMetadata, error) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	account, found := sm.accounts[address]
+	if !found {
+		signingMetadata, err := sm.signingMetadataProvider.SigningMetadataForAccount(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+
+		account = &accountSequence{
+			accountNumber: signingMetadata.AccountNumber(),
+			nextSequence:  signingMetadata.Sequence(),
+		}
+		sm.accounts[address] = account
+		sm.logger.Debug("synced account sequence from chain", "address", address, "sequence", account.nextSequence)
+	}
+
+	sequence := account.nextSequence
+	account.nextSequence++
+
+	return &SigningMetadata{
+		address:       address,
+		accountNumber: account.accountNumber,
+		chainID:       sm.signingMetadataProvider.chainID,
+		sequence:      sequence,
+	}, nil
+}
+
+func (sm *sequenceManager) ResetSequence(address string, nextSequence uint64) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	account, found := sm.accounts[address]
+	if !found {
+		// Without an account number the chain must be queried anyway
+		return
+	}
+
+	sm.logger.Debug("reset account sequence", "address", address, "old_sequence", account.nextSequence, "new_sequence", nextSequence)
+	account.nextSequence = nextSequence
+}
+
+func (sm *sequenceManager) ReleaseSequence(address string, sequence uint64) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	account, found := sm.accounts[address]
+	if !found {
+		return
+	}
+
+	if account.nextSequence == sequence+1 {
+		sm.logger.Debug("released account sequence", "address", address, "sequence", sequence)
+		account.nextSequence = sequence
+		return
+	}
+
+	// Rewinding would hand out sequences which later txs already use
+	sm.logger.Debug("released account sequence is not the latest, resyncing", "address", address, "sequence", sequence, "next_sequence", account.nextSequence)
+	delete(sm.accounts, address)
+}
+
+func (sm *sequenceManager) Resync(address string) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	delete(sm.accounts, address)
+}
+
+// Helpers
+
+// Matches logs like "account sequence mismatch, expected 12, got 11: incorrect account sequence"
+var sequenceMismatchPattern = regexp.MustCompile(`account sequence mismatch, expected (\d+), got (\d+)`)
+
+// ParseExpectedSequence extracts the sequence the chain expected from a sequence mismatch's raw log. Returns false if the log is not in a
+// recognized format.
+func ParseExpectedSequence(rawLog string) (uint64, bool) {
+	matches := sequenceMismatchPattern.FindStringSubmatch(rawLog)
+	if len(matches) != 3 {
+		return 0, false
+	}
+
+	expected, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return expected, true
+}
@@ -0,0 +1,168 @@
+package tx_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// sequenceRpcClient is a chain which checks sequences. Account queries report committedSequence, which may lag behind the sequence the
+// chain expects next. The chain stays at height 1. Other RPCs are not implemented.
+type sequenceRpcClient struct {
+	rpc.RpcClient
+
+	committedSequence uint64
+	expectedSequence  uint64
+	accountQueries    int
+}
+
+func (c *sequenceRpcClient) Account(ctx context.Context, address string) (authtypes.AccountI, error) {
+	c.accountQueries++
+	return &authtypes.BaseAccount{AccountNumber: 1, Sequence: c.committedSequence}, nil
+}
+
+func (c *sequenceRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	return 1, nil
+}
+
+func (c *sequenceRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	sequence, err := strconv.ParseUint(string(txBytes), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if sequence != c.expectedSequence {
+		rawLog := fmt.Sprintf("account sequence mismatch, expected %d, got %d: incorrect account sequence", c.expectedSequence, sequence)
+		return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{Codespace: "sdk", Code: 32, RawLog: rawLog}}, nil
+	}
+	c.expectedSequence++
+
+	return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: string(txBytes), GasWanted: 100}}, nil
+}
+
+func (c *sequenceRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	return &txtypes.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: txHash, GasWanted: 100, GasUsed: 90}}, nil
+}
+
+// sequenceTxProvider "signs" txs by encoding their sequence.
+type sequenceTxProvider struct{}
+
+func (p *sequenceTxProvider) GetFeeDenom() string {
+	return "ufoo"
+}
+
+func (p *sequenceTxProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *tx.SigningMetadata) ([]byte, int64, error) {
+	return []byte(strconv.FormatUint(metadata.Sequence(), 10)), 100, nil
+}
+
+// addressSigner has an address, and nothing else.
+type addressSigner struct {
+	crypto.BytesSigner
+}
+
+func (s *addressSigner) GetAddress(prefix string) string {
+	return prefix + "1address"
+}
+
+func TestSequenceManager_HandsOutSequences(t *testing.T) {
+	rpcClient := &sequenceRpcClient{committedSequence: 5}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Sequences are handed out without querying again
+	for _, expected := range []uint64{5, 6, 7} {
+		signingMetadata, err := sequenceManager.NextSigningMetadata(ctx, "address")
+		require.NoError(t, err)
+		require.Equal(t, expected, signingMetadata.Sequence())
+		require.Equal(t, "chain-1", signingMetadata.ChainID())
+	}
+	require.Equal(t, 1, rpcClient.accountQueries)
+
+	sequenceManager.ResetSequence("address", 6)
+	signingMetadata, err := sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), signingMetadata.Sequence())
+
+	sequenceManager.Resync("address")
+	signingMetadata, err = sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), signingMetadata.Sequence())
+	require.Equal(t, 2, rpcClient.accountQueries)
+}
+
+func TestSequenceManager_ReleasesOnlyTheLatestSequence(t *testing.T) {
+	rpcClient := &sequenceRpcClient{committedSequence: 5}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// The latest sequence is handed out again
+	signingMetadata, err := sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	sequenceManager.ReleaseSequence("address", signingMetadata.Sequence())
+	signingMetadata, err = sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), signingMetadata.Sequence())
+	require.Equal(t, 1, rpcClient.accountQueries)
+
+	// An earlier sequence is not, since a later tx already uses the sequence after it
+	_, err = sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	sequenceManager.ReleaseSequence("address", 5)
+	rpcClient.committedSequence = 6
+	signingMetadata, err = sequenceManager.NextSigningMetadata(ctx, "address")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), signingMetadata.Sequence())
+	require.Equal(t, 2, rpcClient.accountQueries)
+}
+
+func TestParseExpectedSequence(t *testing.T) {
+	expected, found := tx.ParseExpectedSequence("account sequence mismatch, expected 12, got 11: incorrect account sequence")
+	require.True(t, found)
+	require.Equal(t, uint64(12), expected)
+
+	_, found = tx.ParseExpectedSequence("insufficient fees")
+	require.False(t, found)
+}
+
+func TestBroadcaster_RecoversFromSequenceMismatch(t *testing.T) {
+	// Txs from this account are in the mempool, so the chain expects a later sequence than has been committed
+	rpcClient := &sequenceRpcClient{committedSequence: 5, expectedSequence: 7}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithSequenceManager(sequenceManager),
+	)
+	require.NoError(t, err)
+
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "7", txHash)
+
+	// The next tx uses the local sequence
+	txHash, err = broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "8", txHash)
+	require.Equal(t, 1, rpcClient.accountQueries)
+}
@@ -0,0 +1,190 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// SignerPoolAccount is an account in a signer pool.
+type SignerPoolAccount struct {
+	Signer crypto.BytesSigner
+
+	// Gas state for the account. Accounts may share a gas manager, or each have their own.
+	GasManager GasManager
+
+	// Signs txs with Signer
+	TxProvider TxProvider
+}
+
+// An account, and what is known about it. Fields are only accessed by the caller which has checked the account out of the pool.
+type pooledSigner struct {
+	address     string
+	broadcaster *Broadcaster
+
+	// The last balance seen, and when
+	balance          sdk.Int
+	balanceCheckedAt time.Time
+}
+
+// SignerPoolBroadcaster spreads txs across a pool of accounts, so that several txs can be in flight at once.
+//
+// Each call takes whichever account is idle and sends its tx with that account's broadcaster. Accounts track their own sequences and gas
+// state. Accounts whose balance in the fee denom is below a minimum are skipped, and their balance is checked again after an interval.
+type SignerPoolBroadcaster struct {
+	// Parameters
+	feeDenom             string
+	minBalance           sdk.Int
+	balanceCheckInterval time.Duration
+
+	// Idle accounts
+	idle    chan *pooledSigner
+	signers int
+
+	// Services
+	logger    *log.Logger
+	rpcClient rpc.RpcClient
+}
+
+var _ MsgBroadcaster = (*SignerPoolBroadcaster)(nil)
+
+// NewSignerPoolBroadcaster creates a broadcaster for a pool of accounts. Each account gets a retryable broadcaster with polling and gas
+// management, configured like NewDefaultBroadcaster, and its own sequence manager.
+func NewSignerPoolBroadcaster(
+	chainName string,
+	bech32Prefix string,
+	accounts []SignerPoolAccount,
+	minBalance sdk.Int,
+	balanceCheckInterval time.Duration,
+	logger *log.Logger,
+	rpcClient rpc.RpcClient,
+	signingMetadataProvider *SigningMetadataProvider,
+
+	txPollAttempts uint,
+	txPollDelay time.Duration,
+
+	retryAttempts uint,
+	retryDelay time.Duration,
+) (*SignerPoolBroadcaster, error) {
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("signer pool must have at least one account")
+	}
+
+	// Balances are checked in the fee denom, so every account must pay fees in it
+	feeDenom := accounts[0].TxProvider.GetFeeDenom()
+	for _, account := range accounts {
+		if account.TxProvider.GetFeeDenom() != feeDenom {
+			return nil, fmt.Errorf("signer pool accounts must share a fee denom, found %s and %s", feeDenom, account.TxProvider.GetFeeDenom())
+		}
+	}
+
+	idle := make(chan *pooledSigner, len(accounts))
+	for _, account := range accounts {
+		address := account.Signer.GetAddress(bech32Prefix)
+		accountLogger := logger.With("signer", address)
+
+		sequenceManager, err := NewSequenceManager(signingMetadataProvider, accountLogger)
+		if err != nil {
+			return nil, err
+		}
+
+		broadcaster, err := NewDefaultBroadcaster(
+			chainName, bech32Prefix, account.Signer, account.GasManager, accountLogger, rpcClient, signingMetadataProvider,
+			account.TxProvider, txPollAttempts, txPollDelay, retryAttempts, retryDelay, WithSequenceManager(sequenceManager),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		idle <- &pooledSigner{
+			address:     address,
+			broadcaster: broadcaster,
+		}
+	}
+
+	return &SignerPoolBroadcaster{
+		feeDenom:             feeDenom,
+		minBalance:           minBalance,
+		balanceCheckInterval: balanceCheckInterval,
+
+		idle:    idle,
+		signers: len(accounts),
+
+		logger:    logger.With("chain_name", chainName),
+		rpcClient: rpcClient,
+	}, nil
+}
+
+// SignAndBroadcast sends msgs from the next idle, funded account. Blocks until a funded account is idle or ctx ends, and fails with
+// ErrNoFundedSigner if no account is funded.
+func (b *SignerPoolBroadcaster) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (string, error) {
+	signer, err := b.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer b.release(signer)
+
+	b.logger.Debug("sending tx from signer pool", "signer", signer.address)
+	return signer.broadcaster.SignAndBroadcast(ctx, msgs)
+}
+
+// Take an idle account with a sufficient balance out of the pool.
+//
+// Unfunded accounts are held aside until acquire returns, so that waiting for a busy account does not take the same unfunded account out
+// of the pool again. Gives up once every account in the pool has been found to be unfunded.
+func (b *SignerPoolBroadcaster) acquire(ctx context.Context) (*pooledSigner, error) {
+	unfunded := []*pooledSigner{}
+	defer func() {
+		for _, signer := range unfunded {
+			b.release(signer)
+		}
+	}()
+
+	for len(unfunded) < b.signers {
+		var signer *pooledSigner
+		select {
+		case signer = <-b.idle:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		isFunded, err := b.isFunded(ctx, signer)
+		if err != nil {
+			b.release(signer)
+			return nil, err
+		}
+		if isFunded {
+			return signer, nil
+		}
+
+		b.logger.Warn("💸 skipping signer with insufficient balance", "signer", signer.address, "balance", signer.balance, "min_balance", b.minBalance, "denom", b.feeDenom)
+		unfunded = append(unfunded, signer)
+	}
+
+	return nil, ErrNoFundedSigner
+}
+
+func (b *SignerPoolBroadcaster) release(signer *pooledSigner) {
+	b.idle <- signer
+}
+
+// Check whether an account can pay fees, querying its balance if the last check is stale.
+func (b *SignerPoolBroadcaster) isFunded(ctx context.Context, signer *pooledSigner) (bool, error) {
+	if signer.balanceCheckedAt.IsZero() || time.Since(signer.balanceCheckedAt) >= b.balanceCheckInterval {
+		balance, err := b.rpcClient.GetBalance(ctx, signer.address, b.feeDenom)
+		if err != nil {
+			return false, err
+		}
+
+		signer.balance = balance.Amount
+		signer.balanceCheckedAt = time.Now()
+	}
+
+	return signer.balance.GTE(b.minBalance), nil
+}
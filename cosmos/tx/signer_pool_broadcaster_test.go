@@ -0,0 +1,168 @@
+package tx_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/rpc"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/crypto"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// balanceRpcClient reports fixed balances, keyed by address.
+type balanceRpcClient struct {
+	*sequenceRpcClient
+
+	balances map[string]int64
+}
+
+func (c *balanceRpcClient) GetBalance(ctx context.Context, address, denom string) (*sdk.Coin, error) {
+	balance := sdk.NewInt64Coin(denom, c.balances[address])
+	return &balance, nil
+}
+
+// namedSigner has a fixed address, and nothing else.
+type namedSigner struct {
+	crypto.BytesSigner
+
+	address string
+}
+
+func (s *namedSigner) GetAddress(prefix string) string {
+	return s.address
+}
+
+// busyRpcClient blocks the first broadcast until unblocked, keeping its signer busy.
+type busyRpcClient struct {
+	*balanceRpcClient
+
+	started chan struct{}
+	unblock chan struct{}
+	once    sync.Once
+}
+
+func (c *busyRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	c.once.Do(func() {
+		close(c.started)
+		<-c.unblock
+	})
+	return c.balanceRpcClient.Broadcast(ctx, txBytes)
+}
+
+func newSignerPoolBroadcaster(t *testing.T, balances map[string]int64) *tx.SignerPoolBroadcaster {
+	t.Helper()
+
+	rpcClient := &balanceRpcClient{
+		sequenceRpcClient: &sequenceRpcClient{},
+		balances:          balances,
+	}
+	return newSignerPoolBroadcasterWithRpcClient(t, rpcClient)
+}
+
+func newSignerPoolBroadcasterWithRpcClient(t *testing.T, rpcClient rpc.RpcClient) *tx.SignerPoolBroadcaster {
+	t.Helper()
+
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	accounts := []tx.SignerPoolAccount{}
+	for _, address := range []string{"poor", "rich"} {
+		accounts = append(accounts, tx.SignerPoolAccount{
+			Signer:     &namedSigner{address: address},
+			GasManager: newBacktestGasManager(t, 0.01),
+			TxProvider: &sequenceTxProvider{},
+		})
+	}
+
+	broadcaster, err := tx.NewSignerPoolBroadcaster(
+		"chain", "cosmos", accounts, sdk.NewInt(1_000), time.Hour, log.Default(), rpcClient, signingMetadataProvider,
+		1, time.Millisecond, 1, time.Millisecond,
+	)
+	require.NoError(t, err)
+	return broadcaster
+}
+
+func TestSignerPoolBroadcaster_SkipsUnfundedSigners(t *testing.T) {
+	broadcaster := newSignerPoolBroadcaster(t, map[string]int64{"poor": 10, "rich": 10_000})
+
+	// Only the funded signer sends, so its sequences are consecutive
+	for _, expected := range []string{"0", "1", "2"} {
+		txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, txHash)
+	}
+}
+
+func TestSignerPoolBroadcaster_FailsWithoutFundedSigners(t *testing.T) {
+	broadcaster := newSignerPoolBroadcaster(t, map[string]int64{})
+
+	_, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.ErrorIs(t, err, tx.ErrNoFundedSigner)
+}
+
+func TestSignerPoolBroadcaster_WaitsForBusyFundedSigner(t *testing.T) {
+	rpcClient := &busyRpcClient{
+		balanceRpcClient: &balanceRpcClient{
+			sequenceRpcClient: &sequenceRpcClient{},
+			balances:          map[string]int64{"poor": 10, "rich": 10_000},
+		},
+		started: make(chan struct{}),
+		unblock: make(chan struct{}),
+	}
+	broadcaster := newSignerPoolBroadcasterWithRpcClient(t, rpcClient)
+
+	// The funded signer is busy with a tx, and only the unfunded signer is idle
+	firstTx := make(chan error)
+	go func() {
+		_, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+		firstTx <- err
+	}()
+	<-rpcClient.started
+
+	secondTx := make(chan error)
+	go func() {
+		_, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+		secondTx <- err
+	}()
+
+	// The second tx waits for the funded signer rather than failing
+	select {
+	case err := <-secondTx:
+		t.Fatalf("second tx finished while the funded signer was busy: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(rpcClient.unblock)
+	require.NoError(t, <-firstTx)
+	require.NoError(t, <-secondTx)
+}
+
+func TestSignerPoolBroadcaster_WaitingHonorsContext(t *testing.T) {
+	rpcClient := &busyRpcClient{
+		balanceRpcClient: &balanceRpcClient{
+			sequenceRpcClient: &sequenceRpcClient{},
+			balances:          map[string]int64{"poor": 10, "rich": 10_000},
+		},
+		started: make(chan struct{}),
+		unblock: make(chan struct{}),
+	}
+	defer close(rpcClient.unblock)
+	broadcaster := newSignerPoolBroadcasterWithRpcClient(t, rpcClient)
+
+	go func() {
+		_, _ = broadcaster.SignAndBroadcast(context.Background(), nil)
+	}()
+	<-rpcClient.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := broadcaster.SignAndBroadcast(ctx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -2,10 +2,13 @@ package tx
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/tessellated-io/pickaxe/cosmos/abci"
 	"github.com/tessellated-io/pickaxe/cosmos/rpc"
 	"github.com/tessellated-io/pickaxe/crypto"
 	"github.com/tessellated-io/pickaxe/log"
@@ -16,14 +19,34 @@ import (
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 )
 
+// MsgBroadcaster signs and broadcasts msgs in a tx, and waits for the tx to land.
+type MsgBroadcaster interface {
+	SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (txHash string, err error)
+}
+
 // Broadcaster wraps TxBroadcaster. You probably just want to use NewDefaultBroadcaster.
 
 type Broadcaster struct {
-	logger  *log.Logger
-	wrapped TxBroadcaster
+	chainName string
+	address   string
+
+	// Set once journaled txs have been resumed
+	resumed    bool
+	resumeLock *sync.Mutex
+
+	hooks     *txHooks
+	journal   TxJournal
+	logger    *log.Logger
+	rpcClient rpc.RpcClient
+	wrapped   TxBroadcaster
+
+	// Polls for journaled txs without gas tracking, since resumed txs were not priced by this process
+	resumePoller TxBroadcaster
 }
 
-// Retryable broadcaster with polling and gas management.
+var _ MsgBroadcaster = (*Broadcaster)(nil)
+
+// Retryable broadcaster with polling and gas management. Fees are paid in the txProvider's fee denom.
 func NewDefaultBroadcaster(
 	chainName string,
 	bech32Prefix string,
@@ -39,36 +62,50 @@ func NewDefaultBroadcaster(
 
 	retryAttempts uint,
 	retryDelay time.Duration,
+
+	opts ...TxBroadcasterOption,
 ) (*Broadcaster, error) {
-	txb1, err := NewDefaultTxBroadcaster(chainName, bech32Prefix, signer, gasManager, logger, rpcClient, signingMetadataProvider, txProvider)
-	if err != nil {
-		return nil, err
-	}
+	txb1 := newDefaultTxBroadcaster(chainName, bech32Prefix, signer, gasManager, logger, rpcClient, signingMetadataProvider, txProvider, opts...)
 
 	txb2, err := NewPollingTxBroadcaster(txPollAttempts, txPollDelay, logger, txb1)
 	if err != nil {
 		return nil, err
 	}
 
-	txb3, err := NewGasTrackingTxBroadcaster(chainName, gasManager, logger, txb2)
-	if err != nil {
-		return nil, err
-	}
-
-	txb4, err := NewRetryableBroadcaster(retryAttempts, retryDelay, logger, txb3)
-	if err != nil {
-		return nil, err
-	}
+	txb3 := newGasTrackingTxBroadcaster(chainName, gasManager, logger, txb2, txb1.hooks, txb1.gasFactors)
+	txb4 := newRetryableBroadcaster(retryAttempts, retryDelay, logger, txb3, txb1.hooks)
 
 	broadcaster := &Broadcaster{
-		logger:  logger,
-		wrapped: txb4,
+		chainName: chainName,
+		address:   signer.GetAddress(bech32Prefix),
+
+		resumeLock: &sync.Mutex{},
+
+		hooks:     txb1.hooks,
+		journal:   txb1.journal,
+		logger:    logger,
+		rpcClient: rpcClient,
+		wrapped:   txb4,
+
+		resumePoller: txb2,
 	}
 
 	return broadcaster, nil
 }
 
 func (b *Broadcaster) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (txHash string, err error) {
+	// Follow up txs from before a restart, so they cannot land after new txs are signed
+	err = b.Resume(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Txs sent for msgs, which hooks no longer need to know about once msgs are done
+	txHashes := []string{}
+	defer func() {
+		b.hooks.forget(txHashes...)
+	}()
+
 	for {
 		// Ditch if context has timed out
 		if ctx.Err() != nil {
@@ -89,17 +126,18 @@ func (b *Broadcaster) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (txH
 		if isSuccessErr != nil {
 			panic("broadcaster::should never happen")
 		}
+		txHashes = append(txHashes, broadcastResult.TxResponse.TxHash)
 
 		if !isSuccess {
 			// If the broadcast result is a gas error, retry
 			codespace := broadcastResult.TxResponse.Codespace
 			code := broadcastResult.TxResponse.Code
-			logs := broadcastResult.TxResponse.RawLog
-			err := errors.New(logs)
+			err := abci.ForChain(b.chainName).FromTxResponse(broadcastResult.TxResponse)
 			logger := logger.With("codespace", codespace, "code", code)
 
-			if IsGasRelatedError(codespace, code) {
+			if IsGasError(err) {
 				logger.Error("failed to sign and broadcast due to gas, will retry", "error", err.Error())
+				b.notifyRetry(ctx, broadcastResult.TxResponse.TxHash, err)
 				continue
 			}
 
@@ -117,38 +155,164 @@ func (b *Broadcaster) SignAndBroadcast(ctx context.Context, msgs []sdk.Msg) (txH
 			return "", err
 		}
 
-		if txStatus != nil && err == nil {
-			// We got a tx status, so something is confirmed. Check if it was a gas error and retry if so.
-			codespace := txStatus.TxResponse.Codespace
-			code := txStatus.TxResponse.Code
-			if IsGasRelatedError(codespace, code) {
-				logger.Error("transaction landed on chain but failed due to gas, will retry", "error", fmt.Errorf("detected gas error in broadcast: %s", txStatus.TxResponse.RawLog))
-
+		if txStatus == nil {
+			event := b.hooks.event(txHash, nil)
+			b.hooks.notify(func(hooks BroadcastHooks) {
+				hooks.TxNotFound(ctx, event)
+			})
+
+			// If the chain has passed the tx's timeout height, the tx can never land, so it is safe to rebroadcast. Gas was already adjusted
+			// for the inclusion failure, so the rebroadcast pays a higher fee.
+			landedStatus, timedOut, err := b.wrapped.checkTimedOut(ctx, txHash)
+			if err != nil && !errors.Is(err, ErrNoTimeoutHeight) {
+				b.logger.Error("failed to check whether tx timed out", "tx_hash", txHash, "error", err.Error())
+				return "", err
+			}
+			if timedOut {
+				b.logger.Warn("transaction passed its timeout height without landing, will rebroadcast", "tx_hash", txHash)
+				b.notifyRetry(ctx, txHash, ErrTxTimedOut)
 				continue
 			}
 
-			// Otherwise, there is nothing we can do. Hot swap to an error though if needed.
-			isSuccess := IsSuccessTxStatus(txStatus)
-			if isSuccess {
-				b.logger.Info("transaction sent and landed on chain, successfully.")
-				return txHash, nil
-			} else {
-				err := errors.New(txStatus.TxResponse.RawLog)
-				b.logger.Error("transaction sent and landed on chain but failed due to non-gas related error", "error", err.Error())
-				return txHash, err
+			if landedStatus == nil {
+				// We didn't find the transaction, and we didn't get an error. Tough to say, but let's ditch since rebroadcasting could be
+				// dangerous in case a bunch of txs settle in O(hours)
+				err = fmt.Errorf("transaction status not found, consider increasing the gas fee")
+				b.logger.Error("failed to get tx status", "error", err.Error())
+				return "", err
 			}
+
+			// The tx landed while waiting for its timeout height, so handle it like any other included tx
+			b.logger.Info("transaction landed before its timeout height", "tx_hash", txHash)
+			txStatus = landedStatus
 		}
 
-		if txStatus == nil && err == nil {
-			// We didn't find the transaction, and we didn't get an error. Tough to say, but let's ditch since rebroadcasting could be dangerous
-			// in case a bunch of txs settle in O(hours)
-			err := fmt.Errorf("transaction status not found, consider increasing the gas fee")
-			b.logger.Error("failed to get tx status", "error", err.Error())
-			return "", err
+		// We got a tx status, so something is confirmed. Check if it was a gas error and retry if so.
+		txErr := abci.ForChain(b.chainName).FromTxResponse(txStatus.TxResponse)
+		if IsGasError(txErr) {
+			logger.Error("transaction landed on chain but failed due to gas, will retry", "error", fmt.Errorf("detected gas error in broadcast: %s", txStatus.TxResponse.RawLog))
+			b.notifyRetry(ctx, txHash, txErr)
+
+			continue
+		}
+
+		// Otherwise, there is nothing we can do. Hot swap to an error though if needed.
+		isSuccess = IsSuccessTxStatus(txStatus)
+		if isSuccess {
+			b.logger.Info("transaction sent and landed on chain, successfully.")
+			return txHash, nil
+		} else {
+			b.logger.Error("transaction sent and landed on chain but failed due to non-gas related error", "error", txErr.Error())
+			return txHash, txErr
+		}
+	}
+}
+
+// Resume follows up journaled txs which were never resolved, for instance because the process stopped while they were in flight.
+//
+// Each tx's bytes are rebroadcast, in case it was dropped from the mempool, and then its inclusion is polled for. Identical bytes cannot
+// land twice, so rebroadcasting is safe. A tx is only marked dropped once it can no longer land: when the account's sequence on chain has
+// moved past the tx's sequence without the tx being found, or when the chain passes the tx's timeout height. Txs with a timeout height are
+// waited on until one of these happens. Txs without one are left pending if they are not found, and new txs are sent. A new tx takes the
+// pending tx's sequence, so only one of them can land, and the pending tx is resolved by the next Resume once its sequence is used. This
+// also resolves txs whose broadcast errored.
+//
+// SignAndBroadcast resumes before sending its first tx, so Resume only needs to be called to resume eagerly. Does nothing without a
+// journal, or once txs have been resumed.
+func (b *Broadcaster) Resume(ctx context.Context) error {
+	b.resumeLock.Lock()
+	defer b.resumeLock.Unlock()
+
+	if b.resumed || b.journal == nil {
+		return nil
+	}
+
+	entries, err := b.journal.Unresolved(b.chainName, b.address)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		b.logger.Info("📒 resuming unresolved txs from journal", "txs", len(entries))
+	}
+
+	for _, entry := range entries {
+		err := b.resumeTx(ctx, entry)
+		if err != nil {
+			b.logger.Error("failed to resume tx from journal", "tx_hash", entry.TxHash, "error", err.Error())
+			return err
 		}
+	}
 
-		panic("invalid status")
+	b.resumed = true
+	return nil
+}
+
+func (b *Broadcaster) resumeTx(ctx context.Context, entry *TxJournalEntry) error {
+	logger := b.logger.With("tx_hash", entry.TxHash, "sequence", entry.Sequence)
+
+	// Rebroadcasting is best effort, since the tx may already be in the mempool or on chain
+	result, err := b.rpcClient.Broadcast(ctx, entry.TxBytes)
+	if err != nil {
+		logger.Warn("failed to rebroadcast journaled tx", "error", err.Error())
+	} else if result != nil && result.TxResponse != nil {
+		// Txs which are in the mempool or landed already fail CheckTx, which is expected
+		logger.Debug("rebroadcast journaled tx", "code", result.TxResponse.Code, "codespace", result.TxResponse.Codespace)
 	}
+
+	// Poll until the tx lands, its sequence is used, or the chain passes its timeout height
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Read the height and sequence before the status, so a tx which is not found past its timeout height or sequence can never land
+		var height int64
+		if entry.TimeoutHeight > 0 {
+			height, err = b.rpcClient.GetLatestBlockHeight(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		account, err := b.rpcClient.Account(ctx, b.address)
+		if err != nil {
+			return err
+		}
+
+		// Found txs are resolved as they are found
+		txStatus, err := b.resumePoller.checkTxStatus(ctx, entry.TxHash)
+		if err != nil {
+			return err
+		}
+		if txStatus != nil {
+			logger.Info("resumed tx landed on chain", "code", txStatus.TxResponse.Code)
+			return nil
+		}
+
+		if account.GetSequence() > entry.Sequence {
+			logger.Warn("resumed tx was not found and its sequence was used, marking it dropped", "account_sequence", account.GetSequence())
+			return b.journal.Resolve(b.chainName, entry.TxHash, TxJournalDropped, ErrTxSequenceUsed)
+		}
+
+		// Without a timeout height the tx may land at any time, so it is left pending until its sequence is used
+		if entry.TimeoutHeight == 0 {
+			logger.Warn("resumed tx was not found and has no timeout height, leaving it pending until its sequence is used")
+			return nil
+		}
+
+		if uint64(height) > entry.TimeoutHeight {
+			logger.Warn("resumed tx passed its timeout height without landing, marking it dropped", "height", height, "timeout_height", entry.TimeoutHeight)
+			return b.journal.Resolve(b.chainName, entry.TxHash, TxJournalDropped, ErrTxTimedOut)
+		}
+		logger.Info("resumed tx was not found, waiting for its timeout height", "height", height, "timeout_height", entry.TimeoutHeight)
+	}
+}
+
+// Tell hooks that msgs will be sent again because of err in txHash.
+func (b *Broadcaster) notifyRetry(ctx context.Context, txHash string, err error) {
+	event := b.hooks.event(txHash, err)
+	b.hooks.notify(func(hooks BroadcastHooks) {
+		hooks.RetryScheduled(ctx, event)
+	})
 }
 
 // Broadcasts transactions reliably, and with retries
@@ -158,12 +322,20 @@ type TxBroadcaster interface {
 
 	// Pass back a tx status. If tx status is "not found" then pass back (nil, nil)
 	checkTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error)
+
+	// Pass back the denom fees are paid in
+	getFeeDenom() string
+
+	// Pass back whether a tx which was not found can no longer land, because the chain has passed its timeout height. If the tx lands
+	// before its timeout height, pass back its status instead. Returns ErrNoTimeoutHeight if the tx was not sent with a timeout height.
+	checkTimedOut(ctx context.Context, txHash string) (txStatus *txtypes.GetTxResponse, timedOut bool, err error)
 }
 
 // default broadcaster simply broadcasts transactions
 type defaultBroadcaster struct {
 	// Parameters
 	chainName    string
+	feeDenom     string
 	bech32Prefix string
 	signer       crypto.BytesSigner
 
@@ -173,10 +345,71 @@ type defaultBroadcaster struct {
 	rpcClient               rpc.RpcClient
 	signingMetadataProvider *SigningMetadataProvider
 	txProvider              TxProvider
+
+	// Optional. If set, sequences are tracked locally instead of being queried for every tx.
+	sequenceManager SequenceManager
+
+	// Txs time out this many blocks after the height they were signed at. Zero if txs do not time out.
+	timeoutBlocks uint64
+
+	// Txs with a timeout height that have not been found yet, keyed by tx hash
+	unconfirmed     map[string]*unconfirmedTx
+	unconfirmedLock *sync.Mutex
+
+	// Hooks registered with WithHooks
+	hooks *txHooks
+
+	// Optional. If set, txs are journaled before they are broadcast.
+	journal TxJournal
+
+	// Gas factors accepted txs were built with, for gas managers which learn from gas usage
+	gasFactors *txGasFactors
+}
+
+// A broadcasted tx with a timeout height
+type unconfirmedTx struct {
+	timeoutHeight uint64
+	sequence      uint64
 }
 
 var _ TxBroadcaster = (*defaultBroadcaster)(nil)
 
+// DefaultTimeoutBlocks is how many blocks after the chain's current height txs time out, unless set with WithTimeoutBlocks. A few minutes of
+// blocks on most chains, which is long enough for a tx with a competitive fee to land.
+const DefaultTimeoutBlocks uint64 = 50
+
+// How many times a tx is re-signed after a sequence mismatch before the result is returned
+const maxSequenceMismatchRetries = 3
+
+// TxBroadcasterOption configures optional behavior of the default TxBroadcaster.
+type TxBroadcasterOption func(*defaultBroadcaster)
+
+// WithSequenceManager tracks sequences locally with sequenceManager. Txs which fail with a sequence mismatch are re-signed with the sequence
+// the chain expected and retried immediately.
+func WithSequenceManager(sequenceManager SequenceManager) TxBroadcasterOption {
+	return func(b *defaultBroadcaster) {
+		b.sequenceManager = sequenceManager
+	}
+}
+
+// WithJournal records signed txs in journal before they are broadcast, and marks them with their final state. Broadcasters resume txs left
+// unresolved in the journal before sending new txs. A journal may be shared by broadcasters for several chains and accounts.
+func WithJournal(journal TxJournal) TxBroadcasterOption {
+	return func(b *defaultBroadcaster) {
+		b.journal = journal
+	}
+}
+
+// WithTimeoutBlocks sets the timeout height of txs to timeoutBlocks after the chain's current height, instead of DefaultTimeoutBlocks. Once
+// the chain passes the timeout height, a tx which has not landed never will, so it can be safely rebroadcast. Zero disables timeouts, in
+// which case txs which are not found after polling are given up on, and journaled txs which are not found are only resolved once their
+// sequence is used.
+func WithTimeoutBlocks(timeoutBlocks uint64) TxBroadcasterOption {
+	return func(b *defaultBroadcaster) {
+		b.timeoutBlocks = timeoutBlocks
+	}
+}
+
 func NewDefaultTxBroadcaster(
 	chainName string,
 	bech32Prefix string,
@@ -186,9 +419,25 @@ func NewDefaultTxBroadcaster(
 	rpcClient rpc.RpcClient,
 	signingMetadataProvider *SigningMetadataProvider,
 	txProvider TxProvider,
+	opts ...TxBroadcasterOption,
 ) (TxBroadcaster, error) {
+	return newDefaultTxBroadcaster(chainName, bech32Prefix, signer, gasManager, logger, rpcClient, signingMetadataProvider, txProvider, opts...), nil
+}
+
+func newDefaultTxBroadcaster(
+	chainName string,
+	bech32Prefix string,
+	signer crypto.BytesSigner,
+	gasManager GasManager,
+	logger *log.Logger,
+	rpcClient rpc.RpcClient,
+	signingMetadataProvider *SigningMetadataProvider,
+	txProvider TxProvider,
+	opts ...TxBroadcasterOption,
+) *defaultBroadcaster {
 	broadcaster := &defaultBroadcaster{
 		chainName:    chainName,
+		feeDenom:     txProvider.GetFeeDenom(),
 		bech32Prefix: bech32Prefix,
 		signer:       signer,
 
@@ -197,46 +446,59 @@ func NewDefaultTxBroadcaster(
 		rpcClient:               rpcClient,
 		signingMetadataProvider: signingMetadataProvider,
 		txProvider:              txProvider,
+
+		timeoutBlocks: DefaultTimeoutBlocks,
+
+		unconfirmed:     make(map[string]*unconfirmedTx),
+		unconfirmedLock: &sync.Mutex{},
+
+		hooks:      newTxHooks(chainName),
+		gasFactors: newTxGasFactors(),
+	}
+	for _, opt := range opts {
+		opt(broadcaster)
 	}
 
-	return broadcaster, nil
+	return broadcaster
 }
 
 // Private helper, incorporating core functionality
 func (b *defaultBroadcaster) signAndBroadcast(ctx context.Context, msgs []sdk.Msg) (broadcastResult *txtypes.BroadcastTxResponse, err error) {
-	logger := b.logger.With("chain_name", b.chainName)
+	logger := b.logger.With("chain_name", b.chainName, "fee_denom", b.feeDenom)
 
 	// Get the gas price, which is needed to sign the message
-	gasPrice, err := b.gasManager.GetGasPrice(b.chainName)
+	gasPrice, err := b.gasManager.GetGasPrice(b.chainName, b.feeDenom)
 	if err != nil {
 		return nil, err
 	}
 	logger.Debug("txbroadcaster received gas price")
 
 	// Get the gas factor, which is needed to simulate the message
-	gasFactor, err := b.gasManager.GetGasFactor(b.chainName)
+	gasFactor, err := b.gasManager.GetGasFactor(b.chainName, b.feeDenom)
 	if err != nil {
 		return nil, err
 	}
 	logger.Debug("txbroadcaster received gas factor")
 
-	// Get the signer's metadata
 	senderAddress := b.signer.GetAddress(b.bech32Prefix)
-	signingMetadata, err := b.signingMetadataProvider.SigningMetadataForAccount(ctx, senderAddress)
-	if err != nil {
-		return nil, err
-	}
-	logger.Debug("txbroadcaster received signer metadata")
 
-	// Formulate and sign the message
-	signedMessage, gasWanted, err := b.txProvider.ProvideTx(ctx, gasPrice, gasFactor, msgs, signingMetadata)
-	if err != nil {
-		return nil, err
-	}
-	logger.Debug("tx broadcaster signed transaction")
+	var result *txtypes.BroadcastTxResponse
+	var broadcastErr error
+	var gasWanted int64
+	for i := 0; ; i++ {
+		result, gasWanted, broadcastErr = b.signAndBroadcastOnce(ctx, gasPrice, gasFactor, msgs, senderAddress)
+		if broadcastErr != nil || !b.shouldRetrySequenceMismatch(result, senderAddress) || i == maxSequenceMismatchRetries {
+			break
+		}
+		logger.Info("🔢 sequence mismatch, re-signing tx", "attempt", i+1, "max_attempts", maxSequenceMismatchRetries)
 
-	// Attempt to broadcast
-	result, broadcastErr := b.rpcClient.Broadcast(ctx, signedMessage)
+		txHash := result.TxResponse.TxHash
+		event := b.hooks.event(txHash, abci.ForChain(b.chainName).FromTxResponse(result.TxResponse))
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			hooks.RetryScheduled(ctx, event)
+		})
+		b.hooks.forget(txHash)
+	}
 
 	// Log results, regardless of what happened
 	if result != nil && result.TxResponse != nil {
@@ -263,16 +525,236 @@ func (b *defaultBroadcaster) signAndBroadcast(ctx context.Context, msgs []sdk.Ms
 	return result, broadcastErr
 }
 
+// Sign and broadcast msgs with the next sequence, returning the broadcast result and the gas the tx wanted.
+func (b *defaultBroadcaster) signAndBroadcastOnce(ctx context.Context, gasPrice, gasFactor float64, msgs []sdk.Msg, senderAddress string) (*txtypes.BroadcastTxResponse, int64, error) {
+	logger := b.logger.With("chain_name", b.chainName, "fee_denom", b.feeDenom)
+
+	// Get the signer's metadata
+	var signingMetadata *SigningMetadata
+	var err error
+	if b.sequenceManager != nil {
+		signingMetadata, err = b.sequenceManager.NextSigningMetadata(ctx, senderAddress)
+	} else {
+		signingMetadata, err = b.signingMetadataProvider.SigningMetadataForAccount(ctx, senderAddress)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	logger.Debug("txbroadcaster received signer metadata", "sequence", signingMetadata.Sequence())
+
+	// Time the tx out relative to the current height
+	if b.timeoutBlocks > 0 {
+		height, err := b.rpcClient.GetLatestBlockHeight(ctx)
+		if err != nil {
+			b.releaseSequence(senderAddress, signingMetadata)
+			return nil, 0, err
+		}
+		signingMetadata.timeoutHeight = uint64(height) + b.timeoutBlocks
+	}
+
+	// Formulate and sign the message. The tx provider may build the tx with a different gas factor, for instance from gas profiles.
+	signedMessage, gasWanted, gasFactor, err := provideTx(ctx, b.txProvider, gasPrice, gasFactor, msgs, signingMetadata)
+	if err != nil {
+		b.releaseSequence(senderAddress, signingMetadata)
+		return nil, 0, err
+	}
+	logger.Debug("tx broadcaster signed transaction")
+
+	signedTxHash := TxHash(signedMessage)
+
+	// Journal the tx before it can land
+	if b.journal != nil {
+		err = b.journalTx(signedTxHash, signedMessage, msgs, signingMetadata)
+		if err != nil {
+			b.releaseSequence(senderAddress, signingMetadata)
+			return nil, 0, err
+		}
+	}
+
+	signedEvent := TxEvent{
+		ChainName:     b.chainName,
+		TxHash:        signedTxHash,
+		Sequence:      signingMetadata.Sequence(),
+		TimeoutHeight: signingMetadata.TimeoutHeight(),
+		Fee:           FeeForGas(b.feeDenom, gasPrice, gasWanted),
+		GasPrice:      gasPrice,
+		GasFactor:     gasFactor,
+		GasWanted:     gasWanted,
+	}
+	b.hooks.record(signedEvent)
+	b.hooks.notify(func(hooks BroadcastHooks) {
+		hooks.TxSigned(ctx, signedEvent)
+	})
+
+	// Attempt to broadcast
+	result, err := b.rpcClient.Broadcast(ctx, signedMessage)
+
+	// Identical bytes were already accepted into the mempool, for instance by an earlier attempt whose response was lost. The original tx
+	// may still land, so treat this broadcast as in flight under the hash of the bytes.
+	if err == nil && result != nil && result.TxResponse != nil && errors.Is(abci.ForChain(b.chainName).FromTxResponse(result.TxResponse), abci.ErrTxInMempoolCache) {
+		txHash := TxHash(signedMessage)
+		logger.Info("tx already in mempool, will poll for inclusion", "tx_hash", txHash, "sequence", signingMetadata.Sequence())
+
+		result.TxResponse.Code = 0
+		result.TxResponse.Codespace = ""
+		result.TxResponse.TxHash = txHash
+	}
+	b.notifyBroadcast(ctx, signedTxHash, result, err)
+
+	// Txs failing CheckTx can never land. Txs whose broadcast errored may have made it to the mempool, so they stay pending.
+	if err == nil && result != nil && result.TxResponse != nil && result.TxResponse.Code != 0 {
+		b.resolveJournal(signedTxHash, TxJournalRejected, abci.ForChain(b.chainName).FromTxResponse(result.TxResponse))
+	}
+
+	if err == nil && b.sequenceManager != nil && result != nil && result.TxResponse != nil && result.TxResponse.Code != 0 {
+		// Txs failing CheckTx do not consume their sequence
+		failure := abci.ForChain(b.chainName).FromTxResponse(result.TxResponse)
+		if !errors.Is(failure, abci.ErrSequenceMismatch) {
+			b.releaseSequence(senderAddress, signingMetadata)
+		}
+	}
+
+	// Remember the gas factor accepted txs were built with, for gas managers which learn from gas usage once the txs land
+	if err == nil && result != nil && result.TxResponse != nil && result.TxResponse.Code == 0 {
+		b.gasFactors.record(result.TxResponse.TxHash, gasFactor)
+	}
+
+	// Remember accepted txs' timeout heights, to check them if they are not found
+	if err == nil && signingMetadata.TimeoutHeight() > 0 && result != nil && result.TxResponse != nil && result.TxResponse.Code == 0 {
+		b.unconfirmedLock.Lock()
+		b.unconfirmed[result.TxResponse.TxHash] = &unconfirmedTx{
+			timeoutHeight: signingMetadata.TimeoutHeight(),
+			sequence:      signingMetadata.Sequence(),
+		}
+		b.unconfirmedLock.Unlock()
+	}
+
+	return result, gasWanted, err
+}
+
+// Record a signed tx in the journal.
+func (b *defaultBroadcaster) journalTx(txHash string, txBytes []byte, msgs []sdk.Msg, signingMetadata *SigningMetadata) error {
+	msgsDigest, err := MsgsDigest(msgs)
+	if err != nil {
+		return err
+	}
+
+	return b.journal.Record(&TxJournalEntry{
+		ChainName:     b.chainName,
+		Address:       signingMetadata.Address(),
+		TxHash:        txHash,
+		TxBytes:       txBytes,
+		Sequence:      signingMetadata.Sequence(),
+		TimeoutHeight: signingMetadata.TimeoutHeight(),
+		MsgsDigest:    msgsDigest,
+	})
+}
+
+// Mark a journaled tx with its final state, if there is a journal. Failures are logged, since the tx's outcome is already known.
+func (b *defaultBroadcaster) resolveJournal(txHash string, state TxJournalState, err error) {
+	if b.journal == nil {
+		return
+	}
+
+	resolveErr := b.journal.Resolve(b.chainName, txHash, state, err)
+	if resolveErr != nil {
+		b.logger.Warn("failed to resolve tx in journal", "tx_hash", txHash, "state", state, "error", resolveErr.Error())
+	}
+}
+
+// Tell hooks whether the broadcast of txHash was accepted.
+func (b *defaultBroadcaster) notifyBroadcast(ctx context.Context, txHash string, result *txtypes.BroadcastTxResponse, err error) {
+	if err != nil {
+		// Nothing more will be heard about the tx
+		event := b.hooks.event(txHash, err)
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			hooks.BroadcastRejected(ctx, event)
+		})
+		b.hooks.forget(txHash)
+		return
+	}
+	if result == nil || result.TxResponse == nil {
+		return
+	}
+
+	// Later layers find the tx by the hash in the response
+	if result.TxResponse.TxHash == "" {
+		result.TxResponse.TxHash = txHash
+	}
+
+	event := b.hooks.event(txHash, abci.ForChain(b.chainName).FromTxResponse(result.TxResponse))
+	if result.TxResponse.Code == 0 {
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			hooks.BroadcastAccepted(ctx, event)
+		})
+	} else {
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			hooks.BroadcastRejected(ctx, event)
+		})
+	}
+}
+
+// Hand a sequence which was never used back to the sequence manager, if there is one.
+func (b *defaultBroadcaster) releaseSequence(senderAddress string, signingMetadata *SigningMetadata) {
+	if b.sequenceManager != nil {
+		b.sequenceManager.ReleaseSequence(senderAddress, signingMetadata.Sequence())
+	}
+}
+
+// Determine whether a broadcast result is a sequence mismatch which can be retried, and if so, re-sync the sequence manager.
+func (b *defaultBroadcaster) shouldRetrySequenceMismatch(result *txtypes.BroadcastTxResponse, senderAddress string) bool {
+	if b.sequenceManager == nil || result == nil || result.TxResponse == nil {
+		return false
+	}
+	txResponse := result.TxResponse
+	failure := abci.ForChain(b.chainName).FromTxResponse(txResponse)
+	if !errors.Is(failure, abci.ErrSequenceMismatch) {
+		return false
+	}
+
+	expectedSequence, found := ParseExpectedSequence(txResponse.RawLog)
+	if found {
+		b.sequenceManager.ResetSequence(senderAddress, expectedSequence)
+	} else {
+		b.sequenceManager.Resync(senderAddress)
+	}
+	return true
+}
+
 func (b *defaultBroadcaster) checkTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
 	txStatus, err := b.rpcClient.GetTxStatus(ctx, txHash)
-	codespace := txStatus.TxResponse.Codespace
-	broadcastResponseCode := txStatus.TxResponse.Code
-	logger := b.logger.With("chain_name", b.chainName, "tx_hash", txHash, "code", broadcastResponseCode, "codespace", codespace)
+	logger := b.logger.With("chain_name", b.chainName, "tx_hash", txHash)
 	if err == nil {
+		// Responses are only populated if there was no error
+		codespace := txStatus.TxResponse.Codespace
+		broadcastResponseCode := txStatus.TxResponse.Code
 		logs := txStatus.TxResponse.RawLog
-		logger.Info("got a settled tx status")
+		logger.Info("got a settled tx status", "code", broadcastResponseCode, "codespace", codespace)
 		logger.Debug("full tx logs", "logs", logs)
 
+		b.unconfirmedLock.Lock()
+		delete(b.unconfirmed, txHash)
+		b.unconfirmedLock.Unlock()
+
+		txErr := abci.ForChain(b.chainName).FromTxResponse(txStatus.TxResponse)
+		if IsSuccessTxStatus(txStatus) {
+			b.resolveJournal(txHash, TxJournalIncluded, nil)
+		} else {
+			b.resolveJournal(txHash, TxJournalFailed, txErr)
+		}
+
+		event := b.hooks.event(txHash, txErr)
+		event.GasWanted = txStatus.TxResponse.GasWanted
+		event.GasUsed = txStatus.TxResponse.GasUsed
+		event.Height = txStatus.TxResponse.Height
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			if IsSuccessTxStatus(txStatus) {
+				hooks.TxIncluded(ctx, event)
+			} else {
+				hooks.TxFailed(ctx, event)
+			}
+		})
+
 		return txStatus, nil
 	}
 
@@ -288,6 +770,53 @@ func (b *defaultBroadcaster) checkTxStatus(ctx context.Context, txHash string) (
 	return nil, err
 }
 
+func (b *defaultBroadcaster) getFeeDenom() string {
+	return b.feeDenom
+}
+
+func (b *defaultBroadcaster) checkTimedOut(ctx context.Context, txHash string) (*txtypes.GetTxResponse, bool, error) {
+	b.unconfirmedLock.Lock()
+	unconfirmed, found := b.unconfirmed[txHash]
+	b.unconfirmedLock.Unlock()
+	if !found {
+		// Nothing more will be heard about the tx
+		b.gasFactors.take(txHash)
+		return nil, false, ErrNoTimeoutHeight
+	}
+	logger := b.logger.With("chain_name", b.chainName, "tx_hash", txHash, "timeout_height", unconfirmed.timeoutHeight)
+
+	// Read the height before the status, so a tx which is not found at a height past its timeout can never land
+	height, err := b.rpcClient.GetLatestBlockHeight(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The tx may have landed since it was last checked
+	txStatus, err := b.checkTxStatus(ctx, txHash)
+	if err != nil || txStatus != nil {
+		return txStatus, false, err
+	}
+
+	if uint64(height) <= unconfirmed.timeoutHeight {
+		logger.Debug("tx has not reached its timeout height", "height", height)
+		return nil, false, nil
+	}
+
+	logger.Info("tx passed its timeout height without landing", "height", height)
+	b.unconfirmedLock.Lock()
+	delete(b.unconfirmed, txHash)
+	b.unconfirmedLock.Unlock()
+	b.gasFactors.take(txHash)
+	b.resolveJournal(txHash, TxJournalDropped, ErrTxTimedOut)
+
+	// The tx's sequence was never used, so the rebroadcast can take it if no later txs were signed
+	if b.sequenceManager != nil {
+		b.sequenceManager.ReleaseSequence(b.signer.GetAddress(b.bech32Prefix), unconfirmed.sequence)
+	}
+
+	return nil, true, nil
+}
+
 // Polling broadcaster polls for tx inclusion
 type pollingTxBroadcaster struct {
 	// Parameters
@@ -366,14 +895,42 @@ func (b *pollingTxBroadcaster) checkTxStatus(ctx context.Context, txHash string)
 	return nil, nil
 }
 
+func (b *pollingTxBroadcaster) getFeeDenom() string {
+	return b.wrappedBroadcaster.getFeeDenom()
+}
+
+func (b *pollingTxBroadcaster) checkTimedOut(ctx context.Context, txHash string) (*txtypes.GetTxResponse, bool, error) {
+	logger := b.logger.With("tx_hash", txHash)
+
+	// Polling may finish before the tx's timeout height, so keep polling until the tx lands or the chain passes its timeout height
+	for {
+		txStatus, timedOut, err := b.wrappedBroadcaster.checkTimedOut(ctx, txHash)
+		if err != nil || txStatus != nil || timedOut {
+			return txStatus, timedOut, err
+		}
+		logger.Info("transaction still not included, waiting for its timeout height")
+
+		select {
+		case <-time.After(b.delay):
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
 // gasTrackingTxBroadcaster tracks and updates gas prices
 type gasTrackingTxBroadcaster struct {
 	chainName string
+	feeDenom  string
 
 	// Services
 	gasManager         GasManager
+	hooks              *txHooks
 	logger             *log.Logger
 	wrappedBroadcaster TxBroadcaster
+
+	// Gas factors txs were built with, shared with the default broadcaster which signs them
+	gasFactors *txGasFactors
 }
 
 var _ TxBroadcaster = (*gasTrackingTxBroadcaster)(nil)
@@ -384,15 +941,28 @@ func NewGasTrackingTxBroadcaster(
 	logger *log.Logger,
 	wrappedBroadcaster TxBroadcaster,
 ) (TxBroadcaster, error) {
-	broadcaster := &gasTrackingTxBroadcaster{
+	return newGasTrackingTxBroadcaster(chainName, gasManager, logger, wrappedBroadcaster, newTxHooks(chainName), newTxGasFactors()), nil
+}
+
+func newGasTrackingTxBroadcaster(
+	chainName string,
+	gasManager GasManager,
+	logger *log.Logger,
+	wrappedBroadcaster TxBroadcaster,
+	hooks *txHooks,
+	gasFactors *txGasFactors,
+) *gasTrackingTxBroadcaster {
+	return &gasTrackingTxBroadcaster{
 		chainName: chainName,
+		feeDenom:  wrappedBroadcaster.getFeeDenom(),
 
 		gasManager:         gasManager,
+		hooks:              hooks,
 		logger:             logger,
 		wrappedBroadcaster: wrappedBroadcaster,
-	}
 
-	return broadcaster, nil
+		gasFactors: gasFactors,
+	}
 }
 
 // NOTE: This function is just a pure pass through that does gas management
@@ -414,10 +984,13 @@ func (b *gasTrackingTxBroadcaster) signAndBroadcast(ctx context.Context, msgs []
 	}
 
 	// Otherwise, try to handle the result for gas adjustment
-	gasManagementErr := b.gasManager.ManageFailingBroadcastResult(b.chainName, result)
-	if gasManagementErr != nil {
-		b.logger.Warn("failed to adjust gas due to broadcast result", "error", gasManagementErr)
-	}
+	cause := abci.ForChain(b.chainName).FromTxResponse(result.TxResponse)
+	b.notifyGasAdjustment(ctx, result.TxResponse.TxHash, cause, func(gasManager GasManager) {
+		gasManagementErr := gasManager.ManageFailingBroadcastResult(b.chainName, b.feeDenom, result)
+		if gasManagementErr != nil {
+			b.logger.Warn("failed to adjust gas due to broadcast result", "error", gasManagementErr)
+		}
+	})
 
 	return result, err
 }
@@ -436,10 +1009,12 @@ func (b *gasTrackingTxBroadcaster) checkTxStatus(ctx context.Context, txHash str
 	if err == nil && txStatus == nil {
 		b.logger.Debug("gas_tracking_tx_broadcaster::did not find transaction, but did not get an error, adjusting gas")
 
-		gasManagementErr := b.gasManager.ManageInclusionFailure(b.chainName)
-		if gasManagementErr != nil {
-			b.logger.Warn("failed to adjust gas due to missing tx inclusion", "error", gasManagementErr)
-		}
+		b.notifyGasAdjustment(ctx, txHash, nil, func(gasManager GasManager) {
+			gasManagementErr := gasManager.ManageInclusionFailure(b.chainName, b.feeDenom)
+			if gasManagementErr != nil {
+				b.logger.Warn("failed to adjust gas due to missing tx inclusion", "error", gasManagementErr)
+			}
+		})
 
 		b.logger.Debug("gas_tracking_tx_broadcaster::adjusted gas")
 		return txStatus, err
@@ -447,14 +1022,71 @@ func (b *gasTrackingTxBroadcaster) checkTxStatus(ctx context.Context, txHash str
 
 	// If there is a tx status, try to manage it.
 	b.logger.Debug("gas_tracking_tx_broadcaster::got a check tx result")
-	gasManagementErr := b.gasManager.ManageIncludedTransactionStatus(b.chainName, txStatus)
-	if gasManagementErr != nil {
-		b.logger.Warn("failed to adjust gas due to tx status")
-	}
-	b.logger.Debug("gas_tracking_tx_broadcaster::adjusted gas due to check tx result")
+	b.manageTxStatus(ctx, txHash, txStatus)
 	return txStatus, err
 }
 
+// Adjust gas for the status of an included tx.
+func (b *gasTrackingTxBroadcaster) manageTxStatus(ctx context.Context, txHash string, txStatus *txtypes.GetTxResponse) {
+	cause := abci.ForChain(b.chainName).FromTxResponse(txStatus.TxResponse)
+	gasFactor, knownGasFactor := b.gasFactors.take(txHash)
+	b.notifyGasAdjustment(ctx, txHash, cause, func(gasManager GasManager) {
+		gasManagementErr := manageIncludedTransactionStatus(gasManager, b.chainName, b.feeDenom, txStatus, gasFactor, knownGasFactor)
+		if gasManagementErr != nil {
+			b.logger.Warn("failed to adjust gas due to tx status")
+		}
+	})
+	b.logger.Debug("gas_tracking_tx_broadcaster::adjusted gas due to check tx result")
+}
+
+func (b *gasTrackingTxBroadcaster) getFeeDenom() string {
+	return b.feeDenom
+}
+
+func (b *gasTrackingTxBroadcaster) checkTimedOut(ctx context.Context, txHash string) (*txtypes.GetTxResponse, bool, error) {
+	// The inclusion failure was already managed when the tx was not found, but a tx which landed late still tells us about gas
+	txStatus, timedOut, err := b.wrappedBroadcaster.checkTimedOut(ctx, txHash)
+	if err == nil && txStatus != nil {
+		b.manageTxStatus(ctx, txHash, txStatus)
+	}
+	return txStatus, timedOut, err
+}
+
+// Run manage with the gas manager, and tell hooks if the gas manager reports changing the gas price or gas factor because of cause in
+// txHash. Changes are only collected when hooks are registered.
+func (b *gasTrackingTxBroadcaster) notifyGasAdjustment(ctx context.Context, txHash string, cause error, manage func(gasManager GasManager)) {
+	if !b.hooks.registered() {
+		manage(b.gasManager)
+		return
+	}
+
+	adjustments := &gasAdjustments{}
+	gasManager, reportsAdjustments := withGasAdjustments(b.gasManager, adjustments)
+	if !reportsAdjustments {
+		manage(b.gasManager)
+		return
+	}
+	manage(gasManager)
+
+	if len(adjustments.changes) == 0 {
+		return
+	}
+
+	// Later changes supersede earlier ones
+	event := b.hooks.event(txHash, cause)
+	for _, change := range adjustments.changes {
+		switch change.Kind {
+		case GasPriceChange:
+			event.AdjustedGasPrice = change.NewValue
+		case GasFactorChange:
+			event.AdjustedGasFactor = change.NewValue
+		}
+	}
+	b.hooks.notify(func(hooks BroadcastHooks) {
+		hooks.GasAdjusted(ctx, event)
+	})
+}
+
 // Retrying broadcaster retries broadcasting. Attempts failing due to gas errors are retried
 type retryableTxBroadcaster struct {
 	// Parameters
@@ -462,6 +1094,7 @@ type retryableTxBroadcaster struct {
 	delay    time.Duration
 
 	// Services
+	hooks              *txHooks
 	logger             *log.Logger
 	wrappedBroadcaster TxBroadcaster
 }
@@ -474,15 +1107,24 @@ func NewRetryableBroadcaster(
 	logger *log.Logger,
 	wrappedBroadcaster TxBroadcaster,
 ) (TxBroadcaster, error) {
-	broadcaster := &retryableTxBroadcaster{
+	return newRetryableBroadcaster(attempts, delay, logger, wrappedBroadcaster, newTxHooks("")), nil
+}
+
+func newRetryableBroadcaster(
+	attempts uint,
+	delay time.Duration,
+	logger *log.Logger,
+	wrappedBroadcaster TxBroadcaster,
+	hooks *txHooks,
+) *retryableTxBroadcaster {
+	return &retryableTxBroadcaster{
 		attempts: attempts,
 		delay:    delay,
 
+		hooks:              hooks,
 		logger:             logger,
 		wrappedBroadcaster: wrappedBroadcaster,
 	}
-
-	return broadcaster, nil
 }
 
 func (b *retryableTxBroadcaster) signAndBroadcast(ctx context.Context, msgs []sdk.Msg) (broadcastResult *txtypes.BroadcastTxResponse, err error) {
@@ -510,6 +1152,10 @@ func (b *retryableTxBroadcaster) signAndBroadcast(ctx context.Context, msgs []sd
 
 		// Otherwise, poll and wait.
 		logger.Error("failed to sign and broadcast, will retry")
+		event := b.hooks.event("", err)
+		b.hooks.notify(func(hooks BroadcastHooks) {
+			hooks.RetryScheduled(ctx, event)
+		})
 		time.Sleep(b.delay)
 	}
 	panic("retryable_tx_broadcaster::sign_and_broadcast::should never happen")
@@ -545,6 +1191,39 @@ func (b *retryableTxBroadcaster) checkTxStatus(ctx context.Context, txHash strin
 	panic("retryable_tx_broadcaster::check_tx_status::should never happen")
 }
 
+func (b *retryableTxBroadcaster) getFeeDenom() string {
+	return b.wrappedBroadcaster.getFeeDenom()
+}
+
+func (b *retryableTxBroadcaster) checkTimedOut(ctx context.Context, txHash string) (*txtypes.GetTxResponse, bool, error) {
+	logger := b.logger.With("max_attempts", b.attempts)
+
+	var i uint
+	for i = 0; i < b.attempts; i++ {
+		// Ditch if context has timed out
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+
+		txStatus, timedOut, err := b.wrappedBroadcaster.checkTimedOut(ctx, txHash)
+		if err == nil || errors.Is(err, ErrNoTimeoutHeight) {
+			return txStatus, timedOut, err
+		}
+		logger := logger.With("attempt", i+1, "error", err.Error())
+
+		// Give up if all attempts are exhausted.
+		if i+1 == b.attempts {
+			logger.Error("failed in all attempts to check tx timeout")
+			return txStatus, timedOut, err
+		}
+
+		// Otherwise, poll and wait.
+		logger.Error("failed to check tx timeout, will retry")
+		time.Sleep(b.delay)
+	}
+	panic("retryable_tx_broadcaster::check_timed_out::should never happen")
+}
+
 // Helpers
 
 func IsSuccess(broadcastResult *txtypes.BroadcastTxResponse) (bool, error) {
@@ -563,3 +1242,8 @@ func IsSuccessTxStatus(txStatus *txtypes.GetTxResponse) bool {
 	code := txStatus.TxResponse.Code
 	return code == 0
 }
+
+// TxHash returns the hash a chain reports for signed tx bytes: the uppercase hex SHA-256 of the bytes.
+func TxHash(txBytes []byte) string {
+	return fmt.Sprintf("%X", sha256.Sum256(txBytes))
+}
@@ -0,0 +1,235 @@
+package tx_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// droppingRpcClient accepts the first tx into its mempool and then drops it. Every height query sees blocksPerQuery more blocks.
+type droppingRpcClient struct {
+	*sequenceRpcClient
+
+	height         int64
+	blocksPerQuery int64
+	dropped        bool
+}
+
+func (c *droppingRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	height := c.height
+	c.height += c.blocksPerQuery
+	return height, nil
+}
+
+func (c *droppingRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	if !c.dropped {
+		c.dropped = true
+		return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: "dropped"}}, nil
+	}
+	return c.sequenceRpcClient.Broadcast(ctx, txBytes)
+}
+
+func (c *droppingRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	if txHash == "dropped" {
+		return nil, status.Error(codes.NotFound, "tx not found")
+	}
+	return c.sequenceRpcClient.GetTxStatus(ctx, txHash)
+}
+
+// timeoutTxProvider "signs" txs by encoding their sequence, and records their timeout heights.
+type timeoutTxProvider struct {
+	timeoutHeights []uint64
+}
+
+func (p *timeoutTxProvider) GetFeeDenom() string {
+	return "ufoo"
+}
+
+func (p *timeoutTxProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *tx.SigningMetadata) ([]byte, int64, error) {
+	p.timeoutHeights = append(p.timeoutHeights, metadata.TimeoutHeight())
+	return []byte(strconv.FormatUint(metadata.Sequence(), 10)), 100, nil
+}
+
+func TestBroadcaster_RebroadcastsAfterTimeoutHeight(t *testing.T) {
+	rpcClient := &droppingRpcClient{sequenceRpcClient: &sequenceRpcClient{}, height: 100, blocksPerQuery: 5}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+	txProvider := &timeoutTxProvider{}
+
+	gasManager := newBacktestGasManager(t, 0.01)
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider, txProvider,
+		1, time.Millisecond, 1, time.Millisecond, tx.WithSequenceManager(sequenceManager), tx.WithTimeoutBlocks(2),
+	)
+	require.NoError(t, err)
+
+	// The dropped tx's sequence is reused by the rebroadcast
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "0", txHash)
+	require.Equal(t, []uint64{102, 112}, txProvider.timeoutHeights)
+
+	// The rebroadcast paid a higher fee
+	gasPrice, err := gasManager.GetGasPrice("chain", "ufoo")
+	require.NoError(t, err)
+	require.Greater(t, gasPrice, 0.01)
+}
+
+func TestBroadcaster_WaitsForTimeoutHeightBeforeRebroadcasting(t *testing.T) {
+	rpcClient := &droppingRpcClient{sequenceRpcClient: &sequenceRpcClient{}, height: 100, blocksPerQuery: 1}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+	txProvider := &timeoutTxProvider{}
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider, txProvider,
+		1, time.Millisecond, 1, time.Millisecond, tx.WithSequenceManager(sequenceManager), tx.WithTimeoutBlocks(3),
+	)
+	require.NoError(t, err)
+
+	// Polling finishes before the dropped tx's timeout height, so the broadcaster waits for the chain to pass it and then rebroadcasts
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "0", txHash)
+	require.Len(t, txProvider.timeoutHeights, 2)
+	require.Greater(t, rpcClient.height, int64(txProvider.timeoutHeights[0]))
+}
+
+// lateRpcClient does not find txs until they have been polled for missedPolls times.
+type lateRpcClient struct {
+	*sequenceRpcClient
+
+	missedPolls int
+	polls       int
+	broadcasts  int
+}
+
+func (c *lateRpcClient) GetLatestBlockHeight(ctx context.Context) (int64, error) {
+	return 100, nil
+}
+
+func (c *lateRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	c.broadcasts++
+	return c.sequenceRpcClient.Broadcast(ctx, txBytes)
+}
+
+func (c *lateRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	c.polls++
+	if c.polls <= c.missedPolls {
+		return nil, status.Error(codes.NotFound, "tx not found")
+	}
+	return c.sequenceRpcClient.GetTxStatus(ctx, txHash)
+}
+
+func TestBroadcaster_HandlesTxLandingBeforeTimeoutHeight(t *testing.T) {
+	rpcClient := &lateRpcClient{sequenceRpcClient: &sequenceRpcClient{}, missedPolls: 3}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	hooks := &recordingHooks{}
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&timeoutTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithTimeoutBlocks(10), tx.WithHooks(hooks),
+	)
+	require.NoError(t, err)
+
+	// The tx lands while waiting for its timeout height, so it is included rather than reported as not found or sent again
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "0", txHash)
+	require.Equal(t, 1, rpcClient.broadcasts)
+	require.Contains(t, hooks.stages(), "included")
+}
+
+func TestBroadcaster_GivesUpWithoutTimeoutHeight(t *testing.T) {
+	rpcClient := &droppingRpcClient{sequenceRpcClient: &sequenceRpcClient{}, height: 100, blocksPerQuery: 5}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&timeoutTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithTimeoutBlocks(0),
+	)
+	require.NoError(t, err)
+
+	_, err = broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestBroadcaster_TimesOutTxsByDefault(t *testing.T) {
+	rpcClient := &droppingRpcClient{sequenceRpcClient: &sequenceRpcClient{}, height: 100, blocksPerQuery: 25}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+
+	txProvider := &timeoutTxProvider{}
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		txProvider, 1, time.Millisecond, 1, time.Millisecond,
+	)
+	require.NoError(t, err)
+
+	// The dropped tx times out, so it is rebroadcast rather than given up on
+	_, err = broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, txProvider.timeoutHeights, 2)
+	require.Equal(t, 100+tx.DefaultTimeoutBlocks, txProvider.timeoutHeights[0])
+}
+
+// mempoolCacheRpcClient reports every tx as already in the mempool, without a tx hash, and finds whichever tx is polled for.
+type mempoolCacheRpcClient struct {
+	*sequenceRpcClient
+
+	polledHashes []string
+}
+
+func (c *mempoolCacheRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	return &txtypes.BroadcastTxResponse{TxResponse: &sdk.TxResponse{Codespace: "sdk", Code: 19, RawLog: "tx already in mempool"}}, nil
+}
+
+func (c *mempoolCacheRpcClient) GetTxStatus(ctx context.Context, txHash string) (*txtypes.GetTxResponse, error) {
+	c.polledHashes = append(c.polledHashes, txHash)
+	return c.sequenceRpcClient.GetTxStatus(ctx, txHash)
+}
+
+func TestBroadcaster_PollsForTxAlreadyInMempool(t *testing.T) {
+	rpcClient := &mempoolCacheRpcClient{sequenceRpcClient: &sequenceRpcClient{}}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithSequenceManager(sequenceManager),
+	)
+	require.NoError(t, err)
+
+	// The hash is computed from the signed bytes and polled for
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash([]byte("0")), txHash)
+	require.Equal(t, []string{txHash}, rpcClient.polledHashes)
+
+	// The tx in the mempool holds its sequence
+	signingMetadata, err := sequenceManager.NextSigningMetadata(context.Background(), "cosmos1address")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), signingMetadata.Sequence())
+}
+
+func TestTxHash(t *testing.T) {
+	require.Equal(t, "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855", tx.TxHash([]byte{}))
+}
@@ -0,0 +1,253 @@
+package tx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tessellated-io/pickaxe/log"
+	bolt "go.etcd.io/bbolt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// TxJournalState is how far a journaled tx got.
+type TxJournalState string
+
+const (
+	// The tx was signed, and may be in the mempool or on chain
+	TxJournalPending TxJournalState = "pending"
+
+	// The tx landed on chain and succeeded
+	TxJournalIncluded TxJournalState = "included"
+
+	// The tx landed on chain but failed
+	TxJournalFailed TxJournalState = "failed"
+
+	// The tx failed CheckTx, so it was never in the mempool
+	TxJournalRejected TxJournalState = "rejected"
+
+	// The tx can no longer land, because it passed its timeout height, or its sequence was used by another tx
+	TxJournalDropped TxJournalState = "dropped"
+)
+
+// TxJournalEntry is a signed tx in a journal.
+type TxJournalEntry struct {
+	ChainName     string `json:"chain_name"`
+	Address       string `json:"address"`
+	TxHash        string `json:"tx_hash"`
+	TxBytes       []byte `json:"tx_bytes"`
+	Sequence      uint64 `json:"sequence"`
+	TimeoutHeight uint64 `json:"timeout_height,omitempty"`
+
+	// Identifies the msgs in the tx, see MsgsDigest
+	MsgsDigest string `json:"msgs_digest"`
+
+	State      TxJournalState `json:"state"`
+	Error      string         `json:"error,omitempty"`
+	SignedAt   time.Time      `json:"signed_at"`
+	ResolvedAt time.Time      `json:"resolved_at,omitempty"`
+}
+
+// TxJournal durably records signed txs, so that txs which were in flight when a process stopped can be followed up when it starts again.
+type TxJournal interface {
+	// Record a signed tx as pending. Entries are recorded before they are broadcast, and replace any entry with the same hash.
+	Record(entry *TxJournalEntry) error
+
+	// Mark a tx with its final state. err describes why the tx did not succeed, and may be nil.
+	Resolve(chainName, txHash string, state TxJournalState, err error) error
+
+	// Get the pending txs from address, in sequence order.
+	Unresolved(chainName, address string) ([]*TxJournalEntry, error)
+
+	// Delete resolved entries which were resolved before cutoff. Returns the number of entries deleted.
+	Prune(cutoff time.Time) (int, error)
+
+	Close() error
+}
+
+// boltTxJournalFile is the database file name inside the data directory
+const boltTxJournalFile = "tx_journal.db"
+
+// Bucket in the database, keyed by chain name and tx hash
+var txJournalBucket = []byte("txs")
+
+// boltTxJournal stores a tx journal in an embedded bbolt database. Each write is committed to disk before it returns.
+type boltTxJournal struct {
+	db *bolt.DB
+
+	logger *log.Logger
+}
+
+var _ TxJournal = (*boltTxJournal)(nil)
+
+// NewBoltTxJournal opens, or creates, a tx journal in the data directory.
+func NewBoltTxJournal(logger *log.Logger, dataDirectory string) (TxJournal, error) {
+	file := fmt.Sprintf("%s/%s", dataDirectory, boltTxJournalFile)
+
+	db, err := bolt.Open(file, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open tx journal %s: %w", file, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(txJournalBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	logger.Info("💾 opened tx journal", "file", file)
+
+	return &boltTxJournal{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func (j *boltTxJournal) Record(entry *TxJournalEntry) error {
+	pending := *entry
+	pending.State = TxJournalPending
+	if pending.SignedAt.IsZero() {
+		pending.SignedAt = time.Now()
+	}
+
+	value, err := json.Marshal(&pending)
+	if err != nil {
+		return err
+	}
+
+	return j.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(txJournalBucket).Put(boltKey(entry.ChainName, entry.TxHash), value)
+	})
+}
+
+func (j *boltTxJournal) Resolve(chainName, txHash string, state TxJournalState, err error) error {
+	return j.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(txJournalBucket)
+		key := boltKey(chainName, txHash)
+
+		value := bucket.Get(key)
+		if value == nil {
+			return fmt.Errorf("no journal entry for tx %s on %s", txHash, chainName)
+		}
+
+		entry := &TxJournalEntry{}
+		unmarshalErr := json.Unmarshal(value, entry)
+		if unmarshalErr != nil {
+			return unmarshalErr
+		}
+
+		entry.State = state
+		entry.ResolvedAt = time.Now()
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		updated, marshalErr := json.Marshal(entry)
+		if marshalErr != nil {
+			return marshalErr
+		}
+		return bucket.Put(key, updated)
+	})
+}
+
+func (j *boltTxJournal) Unresolved(chainName, address string) ([]*TxJournalEntry, error) {
+	entries := []*TxJournalEntry{}
+	err := j.db.View(func(tx *bolt.Tx) error {
+		prefix := append([]byte(chainName), boltKeySeparator)
+		cursor := tx.Bucket(txJournalBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			entry := &TxJournalEntry{}
+			err := json.Unmarshal(value, entry)
+			if err != nil {
+				return err
+			}
+
+			if entry.State == TxJournalPending && entry.Address == address {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+	return entries, nil
+}
+
+func (j *boltTxJournal) Prune(cutoff time.Time) (int, error) {
+	pruned := 0
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(txJournalBucket)
+
+		// Collect keys first, since deleting while iterating skips entries
+		keys := [][]byte{}
+		err := bucket.ForEach(func(key, value []byte) error {
+			entry := &TxJournalEntry{}
+			err := json.Unmarshal(value, entry)
+			if err != nil {
+				return err
+			}
+
+			if entry.State != TxJournalPending && entry.ResolvedAt.Before(cutoff) {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			err := bucket.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+		pruned = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	j.logger.Debug("pruned tx journal", "entries", pruned, "cutoff", cutoff)
+	return pruned, nil
+}
+
+func (j *boltTxJournal) Close() error {
+	return j.db.Close()
+}
+
+// MsgsDigest returns a hex SHA-256 digest over the type URLs and encoded bytes of msgs, so a journaled tx can be matched with the msgs it
+// was sent for.
+func MsgsDigest(msgs []sdk.Msg) (string, error) {
+	hasher := sha256.New()
+	for _, msg := range msgs {
+		anyMsg, err := codectypes.NewAnyWithValue(msg)
+		if err != nil {
+			return "", err
+		}
+
+		// Length prefix each part, so that different msgs cannot produce the same input
+		for _, part := range [][]byte{[]byte(anyMsg.TypeUrl), anyMsg.Value} {
+			length := make([]byte, 8)
+			binary.BigEndian.PutUint64(length, uint64(len(part)))
+			hasher.Write(length)
+			hasher.Write(part)
+		}
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
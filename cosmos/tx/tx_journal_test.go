@@ -0,0 +1,186 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tessellated-io/pickaxe/cosmos/tx"
+	"github.com/tessellated-io/pickaxe/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// hashingRpcClient reports tx hashes the way a chain does, and records what it broadcasts.
+type hashingRpcClient struct {
+	*sequenceRpcClient
+
+	broadcasts []string
+}
+
+func (c *hashingRpcClient) Broadcast(ctx context.Context, txBytes []byte) (*txtypes.BroadcastTxResponse, error) {
+	c.broadcasts = append(c.broadcasts, string(txBytes))
+
+	result, err := c.sequenceRpcClient.Broadcast(ctx, txBytes)
+	if err != nil {
+		return nil, err
+	}
+	result.TxResponse.TxHash = tx.TxHash(txBytes)
+	return result, nil
+}
+
+func TestBoltTxJournal_ResolvesEntries(t *testing.T) {
+	journal, err := tx.NewBoltTxJournal(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	defer journal.Close()
+
+	for _, sequence := range []uint64{2, 1} {
+		require.NoError(t, journal.Record(&tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1address", TxHash: string(rune('A' + sequence)), Sequence: sequence}))
+	}
+	require.NoError(t, journal.Record(&tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1other", TxHash: "D"}))
+	require.NoError(t, journal.Record(&tx.TxJournalEntry{ChainName: "other-chain", Address: "cosmos1address", TxHash: "E"}))
+
+	// Pending entries are scoped to a chain and address, in sequence order
+	unresolved, err := journal.Unresolved("chain", "cosmos1address")
+	require.NoError(t, err)
+	require.Len(t, unresolved, 2)
+	require.Equal(t, "B", unresolved[0].TxHash)
+	require.Equal(t, "C", unresolved[1].TxHash)
+	require.Equal(t, tx.TxJournalPending, unresolved[0].State)
+
+	require.NoError(t, journal.Resolve("chain", "B", tx.TxJournalFailed, errors.New("out of gas")))
+	unresolved, err = journal.Unresolved("chain", "cosmos1address")
+	require.NoError(t, err)
+	require.Len(t, unresolved, 1)
+	require.Equal(t, "C", unresolved[0].TxHash)
+
+	require.Error(t, journal.Resolve("chain", "missing", tx.TxJournalIncluded, nil))
+
+	// Only resolved entries are pruned
+	pruned, err := journal.Prune(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+}
+
+func TestBroadcaster_ResumesJournaledTxs(t *testing.T) {
+	dataDirectory := t.TempDir()
+	journal, err := tx.NewBoltTxJournal(log.Default(), dataDirectory)
+	require.NoError(t, err)
+
+	// A tx was in flight when the last process stopped
+	inFlightHash := tx.TxHash([]byte("0"))
+	require.NoError(t, journal.Record(&tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1address", TxHash: inFlightHash, TxBytes: []byte("0")}))
+	require.NoError(t, journal.Close())
+
+	journal, err = tx.NewBoltTxJournal(log.Default(), dataDirectory)
+	require.NoError(t, err)
+	defer journal.Close()
+
+	rpcClient := &hashingRpcClient{sequenceRpcClient: &sequenceRpcClient{}}
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	sequenceManager, err := tx.NewSequenceManager(signingMetadataProvider, log.Default())
+	require.NoError(t, err)
+
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, newBacktestGasManager(t, 0.01), log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithSequenceManager(sequenceManager), tx.WithJournal(journal),
+	)
+	require.NoError(t, err)
+
+	// The in flight tx lands once rebroadcast, so the new tx takes the next sequence
+	rpcClient.committedSequence = 1
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), []sdk.Msg{})
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash([]byte("1")), txHash)
+	require.Equal(t, []string{"0", "1"}, rpcClient.broadcasts)
+
+	// Both txs are resolved
+	unresolved, err := journal.Unresolved("chain", "cosmos1address")
+	require.NoError(t, err)
+	require.Empty(t, unresolved)
+}
+
+// resolutionRecordingJournal records the state each tx is resolved with.
+type resolutionRecordingJournal struct {
+	tx.TxJournal
+
+	states map[string]tx.TxJournalState
+}
+
+func (j *resolutionRecordingJournal) Resolve(chainName, txHash string, state tx.TxJournalState, err error) error {
+	j.states[txHash] = state
+	return j.TxJournal.Resolve(chainName, txHash, state, err)
+}
+
+// Create a broadcaster with a journal holding entries, as if they were in flight when the last process stopped.
+func newResumingBroadcaster(t *testing.T, rpcClient *lateRpcClient, gasManager tx.GasManager, entries ...*tx.TxJournalEntry) (*tx.Broadcaster, *resolutionRecordingJournal) {
+	t.Helper()
+
+	boltJournal, err := tx.NewBoltTxJournal(log.Default(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = boltJournal.Close() })
+	journal := &resolutionRecordingJournal{TxJournal: boltJournal, states: make(map[string]tx.TxJournalState)}
+	for _, entry := range entries {
+		require.NoError(t, journal.Record(entry))
+	}
+
+	signingMetadataProvider, err := tx.NewSigningMetadataProvider("chain-1", rpcClient)
+	require.NoError(t, err)
+	broadcaster, err := tx.NewDefaultBroadcaster(
+		"chain", "cosmos", &addressSigner{}, gasManager, log.Default(), rpcClient, signingMetadataProvider,
+		&sequenceTxProvider{}, 1, time.Millisecond, 1, time.Millisecond, tx.WithJournal(journal),
+	)
+	require.NoError(t, err)
+
+	return broadcaster, journal
+}
+
+func TestBroadcaster_ResumedTxLandsAfterFirstCheck(t *testing.T) {
+	// The chain stays below the tx's timeout height, and the tx is found on the second check
+	rpcClient := &lateRpcClient{sequenceRpcClient: &sequenceRpcClient{}, missedPolls: 1}
+	entry := &tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1address", TxHash: "late", TxBytes: []byte("0"), TimeoutHeight: 110}
+	gasManager := &inclusionFailureCountingGasManager{GasManager: newBacktestGasManager(t, 0.01)}
+	broadcaster, journal := newResumingBroadcaster(t, rpcClient, gasManager, entry)
+
+	require.NoError(t, broadcaster.Resume(context.Background()))
+	require.Equal(t, tx.TxJournalIncluded, journal.states["late"])
+	require.Equal(t, 2, rpcClient.polls)
+
+	// The resumed tx was not priced by this broadcaster, so missing it does not raise gas prices
+	require.Zero(t, gasManager.inclusionFailures)
+}
+
+func TestBroadcaster_DropsResumedTxsOnlyAfterTimeoutHeight(t *testing.T) {
+	// The chain is at height 100, and never finds the txs
+	rpcClient := &lateRpcClient{sequenceRpcClient: &sequenceRpcClient{}, missedPolls: 1_000}
+
+	// A tx past its timeout height can never land, so is dropped
+	timedOut := &tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1address", TxHash: "timed-out", TxBytes: []byte("0"), TimeoutHeight: 99}
+	broadcaster, journal := newResumingBroadcaster(t, rpcClient, newBacktestGasManager(t, 0.01), timedOut)
+	require.NoError(t, broadcaster.Resume(context.Background()))
+	require.Equal(t, tx.TxJournalDropped, journal.states["timed-out"])
+}
+
+func TestBroadcaster_DropsResumedTxsOnceTheirSequenceIsUsed(t *testing.T) {
+	// The chain does not find the tx, its sequence has not been used, and rebroadcasting it fails
+	rpcClient := &lateRpcClient{sequenceRpcClient: &sequenceRpcClient{}, missedPolls: 1}
+	untimed := &tx.TxJournalEntry{ChainName: "chain", Address: "cosmos1address", TxHash: "untimed", TxBytes: []byte("unbroadcastable")}
+	broadcaster, journal := newResumingBroadcaster(t, rpcClient, newBacktestGasManager(t, 0.01), untimed)
+
+	// A tx without a timeout height could still land, so is left pending without holding up new txs
+	require.NoError(t, broadcaster.Resume(context.Background()))
+	require.Empty(t, journal.states)
+	txHash, err := broadcaster.SignAndBroadcast(context.Background(), []sdk.Msg{})
+	require.NoError(t, err)
+	require.Equal(t, "0", txHash)
+
+	// Once another tx used its sequence, the tx can never land, so the next resume drops it
+	rpcClient = &lateRpcClient{sequenceRpcClient: &sequenceRpcClient{committedSequence: 1}, missedPolls: 1}
+	broadcaster, journal = newResumingBroadcaster(t, rpcClient, newBacktestGasManager(t, 0.01), untimed)
+	require.NoError(t, broadcaster.Resume(context.Background()))
+	require.Equal(t, tx.TxJournalDropped, journal.states["untimed"])
+}
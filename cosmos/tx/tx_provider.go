@@ -15,8 +15,37 @@ import (
 
 type TxProvider interface {
 	ProvideTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) ([]byte, int64, error)
+
+	// The denom fees are paid in. Gas prices are kept per fee denom, so broadcasters use this to find them.
+	GetFeeDenom() string
+}
+
+// TxSimulator is a TxProvider which can measure a tx without signing it.
+type TxSimulator interface {
+	// Build and simulate a tx for messages, returning the size the signed tx will have and the gas it wants.
+	SimulateMsgs(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) (int, int64, error)
+}
+
+// gasFactorReportingTxProvider is a TxProvider which may build txs with a different gas factor than it is given, for instance from gas
+// profiles.
+type gasFactorReportingTxProvider interface {
+	// Like ProvideTx, also returning the gas factor the tx was built with.
+	provideTxWithGasFactor(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) ([]byte, int64, float64, error)
+}
+
+// Provide a tx with txProvider, returning the gas factor the tx was built with.
+func provideTx(ctx context.Context, txProvider TxProvider, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) ([]byte, int64, float64, error) {
+	reporting, ok := txProvider.(gasFactorReportingTxProvider)
+	if !ok {
+		txBytes, gasWanted, err := txProvider.ProvideTx(ctx, gasPrice, gasFactor, messages, metadata)
+		return txBytes, gasWanted, gasFactor, err
+	}
+	return reporting.provideTxWithGasFactor(ctx, gasPrice, gasFactor, messages, metadata)
 }
 
+// Bytes a secp256k1 signature adds to an encoded tx, which unsigned txs are measured without
+const signatureSize = 64
+
 // txProvider is the default implementation of the Signer interface
 type txProvider struct {
 	bytesSigner crypto.BytesSigner
@@ -28,15 +57,35 @@ type txProvider struct {
 
 	txConfig  client.TxConfig
 	txFactory cosmostx.Factory
+
+	// Optional gas profiles, which override the gas factor for known sets of messages
+	gasProfiles          GasProfiles
+	gasProfilesChainName string
+}
+
+// TxProviderOption configures optional behavior of a TxProvider.
+type TxProviderOption func(*txProvider)
+
+// WithGasProfiles looks up gas factors for txs in profiles, which are keyed by chainName. Feed profiles with gas usage by wrapping the
+// broadcaster's gas manager with NewGasProfilingGasManager.
+func WithGasProfiles(chainName string, profiles GasProfiles) TxProviderOption {
+	return func(txp *txProvider) {
+		txp.gasProfiles = profiles
+		txp.gasProfilesChainName = chainName
+	}
 }
 
 // Assert type conformance
-var _ TxProvider = (*txProvider)(nil)
+var (
+	_ TxProvider                   = (*txProvider)(nil)
+	_ TxSimulator                  = (*txProvider)(nil)
+	_ gasFactorReportingTxProvider = (*txProvider)(nil)
+)
 
-func NewTxProvider(bytesSigner crypto.BytesSigner, chainID, feeDenom, memo string, logger *log.Logger, simulationManager SimulationManager, txConfig client.TxConfig) (TxProvider, error) {
+func NewTxProvider(bytesSigner crypto.BytesSigner, chainID, feeDenom, memo string, logger *log.Logger, simulationManager SimulationManager, txConfig client.TxConfig, opts ...TxProviderOption) (TxProvider, error) {
 	txFactory := cosmostx.Factory{}.WithChainID(chainID).WithTxConfig(txConfig)
 
-	return &txProvider{
+	txp := &txProvider{
 		bytesSigner: bytesSigner,
 		feeDenom:    feeDenom,
 		memo:        memo,
@@ -46,53 +95,38 @@ func NewTxProvider(bytesSigner crypto.BytesSigner, chainID, feeDenom, memo strin
 
 		txConfig:  txConfig,
 		txFactory: txFactory,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(txp)
+	}
+
+	return txp, nil
 }
 
 // Signer Interface
 
+func (txp *txProvider) GetFeeDenom() string {
+	return txp.feeDenom
+}
+
 // Sign returns the set of messages, encoded with metadata, and includes a valid signature.
 // It also includes the gas that was desired. This API is kinda nuts, but I can't find a sane way around it.
 func (txp *txProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) ([]byte, int64, error) {
+	txBytes, gasWanted, _, err := txp.provideTxWithGasFactor(ctx, gasPrice, gasFactor, messages, metadata)
+	return txBytes, gasWanted, err
+}
+
+func (txp *txProvider) provideTxWithGasFactor(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) ([]byte, int64, float64, error) {
 	logger := txp.logger.With("chain_id", metadata.chainID, "account", metadata.address, "sequence", metadata.sequence, "account_number", metadata.accountNumber)
 	logger.Debug("preparing to sign transaction")
 
-	// Build a transaction
-	txb, err := txp.txFactory.BuildUnsignedTx(messages...)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	txb.SetMemo(txp.memo)
-	signatureProto := signing.SignatureV2{
-		PubKey: txp.bytesSigner.GetPublicKey(),
-		Data: &signing.SingleSignatureData{
-			SignMode:  signing.SignMode_SIGN_MODE_DIRECT,
-			Signature: nil,
-		},
-		Sequence: metadata.Sequence(),
-	}
-	err = txb.SetSignatures(signatureProto)
+	gasFactor = txp.gasFactorForMsgs(gasFactor, messages)
+	txb, gasWanted, err := txp.buildTx(ctx, gasPrice, gasFactor, messages, metadata)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 
-	// Simulate the tx
-	simulationResult, err := txp.simulationManager.SimulateTx(ctx, txb.GetTx(), gasFactor)
-	if err != nil {
-		return nil, 0, err
-	}
-	txp.logger.Debug("simulated gas", "gas_units", simulationResult.GasRecommendation)
-	txb.SetGasLimit(uint64(simulationResult.GasRecommendation))
-
-	fee := []sdk.Coin{
-		{
-			Denom:  txp.feeDenom,
-			Amount: sdk.NewInt(int64(gasPrice*float64(simulationResult.GasRecommendation)) + 1),
-		},
-	}
-	txb.SetFeeAmount(fee)
-
 	// Shim metadata into the format Cosmos SDK wants
 	signerData := authsigning.SignerData{
 		ChainID:       metadata.ChainID(),
@@ -104,13 +138,13 @@ func (txp *txProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float6
 	signMode := signing.SignMode_SIGN_MODE_DIRECT
 	unsignedTxBytes, err := txp.txConfig.SignModeHandler().GetSignBytes(signMode, signerData, txb.GetTx())
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 
 	// Sign the bytes
 	signatureBytes, err := txp.bytesSigner.SignBytes(unsignedTxBytes)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 
 	// Reconstruct the signature proto
@@ -118,22 +152,102 @@ func (txp *txProvider) ProvideTx(ctx context.Context, gasPrice, gasFactor float6
 		SignMode:  signMode,
 		Signature: signatureBytes,
 	}
-	signatureProto = signing.SignatureV2{
+	signatureProto := signing.SignatureV2{
 		PubKey:   txp.bytesSigner.GetPublicKey(),
 		Data:     signatureData,
 		Sequence: metadata.Sequence(),
 	}
 	err = txb.SetSignatures(signatureProto)
 	if err != nil {
-		return []byte{}, 0, err
+		return []byte{}, 0, 0, err
 	}
 
 	// Encode to bytes
 	encoder := txp.txConfig.TxEncoder()
 	txBytes, err := encoder(txb.GetTx())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return txBytes, gasWanted, gasFactor, nil
+}
+
+// SimulateMsgs builds and simulates a tx like ProvideTx, without signing it.
+func (txp *txProvider) SimulateMsgs(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) (int, int64, error) {
+	gasFactor = txp.gasFactorForMsgs(gasFactor, messages)
+	txb, gasWanted, err := txp.buildTx(ctx, gasPrice, gasFactor, messages, metadata)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	encoder := txp.txConfig.TxEncoder()
+	txBytes, err := encoder(txb.GetTx())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return len(txBytes) + signatureSize, gasWanted, nil
+}
+
+// Get the gas factor for a tx containing messages, which is the profile's factor if one has been learned and gasFactor otherwise.
+func (txp *txProvider) gasFactorForMsgs(gasFactor float64, messages []sdk.Msg) float64 {
+	if txp.gasProfiles == nil {
+		return gasFactor
+	}
+
+	profileKey := GasProfileKeyForMsgs(messages)
+	profileGasFactor := txp.gasProfiles.GetGasFactor(txp.gasProfilesChainName, profileKey, gasFactor)
+	if profileGasFactor != gasFactor {
+		txp.logger.Debug("using gas factor from gas profile", "profile", profileKey, "chain_gas_factor", gasFactor, "profile_gas_factor", profileGasFactor)
+	}
+	return profileGasFactor
+}
+
+// Build an unsigned tx, with its gas limit and fee set from a simulation. Returns the builder and the gas the tx wants.
+func (txp *txProvider) buildTx(ctx context.Context, gasPrice, gasFactor float64, messages []sdk.Msg, metadata *SigningMetadata) (client.TxBuilder, int64, error) {
+	// Build a transaction
+	txb, err := txp.txFactory.BuildUnsignedTx(messages...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	txb.SetMemo(txp.memo)
+	if metadata.TimeoutHeight() > 0 {
+		txb.SetTimeoutHeight(metadata.TimeoutHeight())
+	}
+	signatureProto := signing.SignatureV2{
+		PubKey: txp.bytesSigner.GetPublicKey(),
+		Data: &signing.SingleSignatureData{
+			SignMode:  signing.SignMode_SIGN_MODE_DIRECT,
+			Signature: nil,
+		},
+		Sequence: metadata.Sequence(),
+	}
+	err = txb.SetSignatures(signatureProto)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return txBytes, simulationResult.GasRecommendation, nil
+	// Simulate the tx
+	simulationResult, err := txp.simulationManager.SimulateTx(ctx, txb.GetTx(), gasFactor)
+	if err != nil {
+		return nil, 0, err
+	}
+	txp.logger.Debug("simulated gas", "gas_units", simulationResult.GasRecommendation)
+	txb.SetGasLimit(uint64(simulationResult.GasRecommendation))
+
+	fee := []sdk.Coin{
+		FeeForGas(txp.feeDenom, gasPrice, simulationResult.GasRecommendation),
+	}
+	txb.SetFeeAmount(fee)
+
+	return txb, simulationResult.GasRecommendation, nil
+}
+
+// FeeForGas returns the fee a tx wanting gasWanted gas pays at gasPrice.
+func FeeForGas(feeDenom string, gasPrice float64, gasWanted int64) sdk.Coin {
+	return sdk.Coin{
+		Denom:  feeDenom,
+		Amount: sdk.NewInt(int64(gasPrice*float64(gasWanted)) + 1),
+	}
 }
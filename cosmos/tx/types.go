@@ -9,6 +9,9 @@ type SigningMetadata struct {
 	accountNumber uint64
 	chainID       string
 	sequence      uint64
+
+	// Zero if the tx does not time out
+	timeoutHeight uint64
 }
 
 func (sm *SigningMetadata) Address() string {
@@ -26,3 +29,7 @@ func (sm *SigningMetadata) ChainID() string {
 func (sm *SigningMetadata) Sequence() uint64 {
 	return sm.sequence
 }
+
+func (sm *SigningMetadata) TimeoutHeight() uint64 {
+	return sm.timeoutHeight
+}